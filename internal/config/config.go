@@ -0,0 +1,146 @@
+// Package config stores settings and remembered state between runs
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileName is the name of the config file inside the config directory
+const fileName = "config.json"
+
+// Config is everything sshlepp remembers between runs
+type Config struct {
+	Hosts          map[string]*HostState `json:"hosts,omitempty"`
+	LocalBookmarks []Bookmark            `json:"local_bookmarks,omitempty"` // Shared by all hosts
+	LocalSort      *Sort                 `json:"local_sort,omitempty"`
+	RemoteSort     *Sort                 `json:"remote_sort,omitempty"`
+
+	path string // Where the config is saved, "" to never save it
+}
+
+// HostState is what is remembered about one SSH host, keyed by its alias
+type HostState struct {
+	LocalDir  string     `json:"local_dir,omitempty"`
+	RemoteDir string     `json:"remote_dir,omitempty"`
+	Bookmarks []Bookmark `json:"bookmarks,omitempty"` // Remote and paired directories
+}
+
+// Sort is how a panel orders its files
+type Sort struct {
+	By         string `json:"by"` // name, size, mtime or ext
+	Descending bool   `json:"descending,omitempty"`
+	DirsFirst  bool   `json:"dirs_first"`
+}
+
+// Bookmark is a named directory, or a local and a remote directory that are opened together
+type Bookmark struct {
+	Name      string `json:"name"`
+	LocalDir  string `json:"local_dir,omitempty"`
+	RemoteDir string `json:"remote_dir,omitempty"`
+}
+
+// DefaultPath returns the location of the config file in the user's config directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	return filepath.Join(dir, "sshlepp", fileName), nil
+}
+
+// Load reads the config file at path, returning an empty config if it doesn't exist yet
+func Load(path string) (*Config, error) {
+	cfg := &Config{path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Host returns the remembered state of a host, adding it if needed
+func (c *Config) Host(name string) *HostState {
+	if c.Hosts == nil {
+		c.Hosts = make(map[string]*HostState)
+	}
+	if c.Hosts[name] == nil {
+		c.Hosts[name] = &HostState{}
+	}
+	return c.Hosts[name]
+}
+
+// Bookmarks returns the bookmarks of a host followed by the local ones
+func (c *Config) Bookmarks(host string) []Bookmark {
+	var bookmarks []Bookmark
+	if state := c.Hosts[host]; state != nil {
+		bookmarks = append(bookmarks, state.Bookmarks...)
+	}
+	return append(bookmarks, c.LocalBookmarks...)
+}
+
+// AddBookmark stores a bookmark with the host when it has a remote directory
+// and with the local ones otherwise, replacing any bookmark of the same name there
+func (c *Config) AddBookmark(host string, bookmark Bookmark) {
+	list := &c.LocalBookmarks
+	if bookmark.RemoteDir != "" {
+		list = &c.Host(host).Bookmarks
+	}
+
+	for i, existing := range *list {
+		if existing.Name == bookmark.Name {
+			(*list)[i] = bookmark
+			return
+		}
+	}
+	*list = append(*list, bookmark)
+}
+
+// RemoveBookmark deletes a bookmark returned by Bookmarks
+func (c *Config) RemoveBookmark(host string, bookmark Bookmark) {
+	list := &c.LocalBookmarks
+	if bookmark.RemoteDir != "" {
+		list = &c.Host(host).Bookmarks
+	}
+
+	for i, existing := range *list {
+		if existing == bookmark {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return
+		}
+	}
+}
+
+// Save writes the config file, replacing it atomically so a crash can't leave it half written
+func (c *Config) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	tempPath := c.path + ".tmp"
+	if err := os.WriteFile(tempPath, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Rename(tempPath, c.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissing(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "sshlepp", fileName))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Hosts) != 0 {
+		t.Errorf("Expected an empty config, got %+v", cfg)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sshlepp", fileName)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	cfg.Host("prod").RemoteDir = "/var/www"
+	cfg.Host("prod").LocalDir = "/home/me/site"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Temporary file was left behind")
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := loaded.Host("prod"); got.LocalDir != "/home/me/site" || got.RemoteDir != "/var/www" {
+		t.Errorf("Unexpected host state: %+v", got)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	cfg := &Config{}
+	logs := Bookmark{Name: "logs", RemoteDir: "/var/log"}
+	deploy := Bookmark{Name: "deploy", LocalDir: "/home/me/site", RemoteDir: "/var/www"}
+	downloads := Bookmark{Name: "downloads", LocalDir: "/home/me/Downloads"}
+	cfg.AddBookmark("prod", logs)
+	cfg.AddBookmark("prod", deploy)
+	cfg.AddBookmark("prod", downloads)
+	cfg.AddBookmark("staging", Bookmark{Name: "logs", RemoteDir: "/srv/logs"})
+
+	got := cfg.Bookmarks("prod")
+	if len(got) != 3 || got[0] != logs || got[1] != deploy || got[2] != downloads {
+		t.Errorf("Unexpected bookmarks for prod: %+v", got)
+	}
+	if got := cfg.Bookmarks("staging"); len(got) != 2 || got[0].RemoteDir != "/srv/logs" || got[1] != downloads {
+		t.Errorf("Local bookmarks should be shared and remote ones kept per host: %+v", got)
+	}
+
+	// A bookmark with the same name replaces the old one
+	logs.RemoteDir = "/var/log/nginx"
+	cfg.AddBookmark("prod", logs)
+	if got := cfg.Bookmarks("prod"); len(got) != 3 || got[0] != logs {
+		t.Errorf("Expected the bookmark to be replaced: %+v", got)
+	}
+
+	cfg.RemoveBookmark("prod", deploy)
+	cfg.RemoveBookmark("prod", downloads)
+	if got := cfg.Bookmarks("prod"); len(got) != 1 || got[0] != logs {
+		t.Errorf("Unexpected bookmarks after removal: %+v", got)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an invalid config to be rejected")
+	}
+}
@@ -0,0 +1,241 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// archiveJob tracks a running archive transfer so its progress can be shown
+type archiveJob struct {
+	label    string
+	bytes    int64
+	fellBack bool // Copying file by file over SFTP because the server has no shell
+	updates  chan tea.Msg
+}
+
+// Archive message types
+type archiveProgressMsg struct {
+	job      *archiveJob
+	bytes    int64
+	fellBack bool
+}
+
+type archiveDoneMsg struct {
+	job *archiveJob
+	err error
+}
+
+// newArchiveJob creates a job whose progress updates are delivered through a channel
+func newArchiveJob(label string) *archiveJob {
+	return &archiveJob{
+		label:   label,
+		updates: make(chan tea.Msg, 1),
+	}
+}
+
+// report sends a progress update, dropping it if the previous one hasn't been shown yet
+func (j *archiveJob) report(msg archiveProgressMsg) {
+	select {
+	case j.updates <- msg:
+	default:
+	}
+}
+
+// View renders the progress of the job
+func (j *archiveJob) View() string {
+	if j.fellBack {
+		return ui.ProgressStyle.Render(fmt.Sprintf("%s: no remote shell, copying over SFTP...", j.label))
+	}
+	return ui.ProgressStyle.Render(fmt.Sprintf("%s: %s transferred", j.label, formatSize(j.bytes)))
+}
+
+// waitForArchiveCmd waits for the next progress update of a job
+func waitForArchiveCmd(job *archiveJob) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-job.updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// handleArchiveDownload downloads the targeted remote entries as a tar stream
+// created on the server, either extracting it into the local directory or
+// saving it as a .tar.gz archive there
+func (m *fileBrowserModel) handleArchiveDownload(extract bool) (tea.Model, tea.Cmd) {
+	if m.focusedPanel != RightPanel || m.archive != nil {
+		return m, nil
+	}
+	files := m.targetFiles()
+	if len(files) == 0 {
+		return m, nil
+	}
+
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+	}
+	remoteDir, localDir, policy := m.remotePath, m.localPath, m.symlinkPolicy
+
+	if extract {
+		m.archive = newArchiveJob(fmt.Sprintf("Downloading %d item(s) via tar", len(names)))
+		return m, tea.Batch(
+			downloadArchiveCmd(m.sshClient, m.archive, remoteDir, names, policy, localDir, ""),
+			waitForArchiveCmd(m.archive),
+		)
+	}
+
+	defaultName := names[0] + ".tar.gz"
+	if len(names) > 1 {
+		defaultName = path.Base(remoteDir) + ".tar.gz"
+		if defaultName == "/.tar.gz" {
+			defaultName = "root.tar.gz"
+		}
+	}
+
+	m.prompt = newPromptModel("Save archive as:", defaultName, func(value string) tea.Cmd {
+		m.archive = newArchiveJob(fmt.Sprintf("Archiving to %s", value))
+		return tea.Batch(
+			downloadArchiveCmd(m.sshClient, m.archive, remoteDir, names, policy, localDir, filepath.Join(localDir, value)),
+			waitForArchiveCmd(m.archive),
+		)
+	})
+	m.prompt.validate = validateFileName
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// downloadArchiveCmd creates a command that streams a server-side archive into
+// archivePath, or extracts it into localDir when archivePath is empty. Servers
+// without a shell get a plain recursive SFTP copy instead, which is archived
+// locally when an archive was asked for.
+func downloadArchiveCmd(client *ssh.Client, job *archiveJob, remoteDir string, names []string, policy ssh.SymlinkPolicy, localDir, archivePath string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		defer close(job.updates)
+		progress := func(bytes int64) {
+			job.report(archiveProgressMsg{job: job, bytes: bytes})
+		}
+
+		var err error
+		if archivePath == "" {
+			err = client.DownloadAndExtract(remoteDir, names, policy, localDir, progress)
+		} else {
+			err = client.DownloadArchiveFile(remoteDir, names, policy, archivePath, progress)
+		}
+
+		if errors.Is(err, ssh.ErrNoShell) {
+			job.updates <- archiveProgressMsg{job: job, fellBack: true}
+			if archivePath != "" {
+				err = client.DownloadArchiveFileSFTP(remoteDir, names, policy, archivePath)
+				return archiveDoneMsg{job: job, err: err}
+			}
+
+			err = nil
+			for _, name := range names {
+				if err = client.DownloadPath(remotePathJoin(remoteDir, name), filepath.Join(localDir, name), policy); err != nil {
+					err = fmt.Errorf("failed to copy %s: %w", name, err)
+					break
+				}
+			}
+		}
+
+		return archiveDoneMsg{job: job, err: err}
+	})
+}
+
+// handleArchiveUpload uploads the targeted local entries as a tar stream
+// extracted by tar on the server into the current remote directory
+func (m *fileBrowserModel) handleArchiveUpload() (tea.Model, tea.Cmd) {
+	if m.focusedPanel != LeftPanel || m.archive != nil {
+		return m, nil
+	}
+	files := m.targetFiles()
+	if len(files) == 0 {
+		return m, nil
+	}
+
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+	}
+
+	m.archive = newArchiveJob(fmt.Sprintf("Uploading %d item(s) via tar", len(names)))
+	return m, tea.Batch(
+		uploadArchiveCmd(m.sshClient, m.archive, m.localPath, names, m.symlinkPolicy, m.remotePath),
+		waitForArchiveCmd(m.archive),
+	)
+}
+
+// uploadArchiveCmd creates a command that streams local entries into tar on
+// the server, or copies them over SFTP when the server has no shell
+func uploadArchiveCmd(client *ssh.Client, job *archiveJob, localDir string, names []string, policy ssh.SymlinkPolicy, remoteDir string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		defer close(job.updates)
+		err := client.UploadArchive(localDir, names, policy, remoteDir, func(bytes int64) {
+			job.report(archiveProgressMsg{job: job, bytes: bytes})
+		})
+
+		if errors.Is(err, ssh.ErrNoShell) {
+			job.updates <- archiveProgressMsg{job: job, fellBack: true}
+			err = nil
+			for _, name := range names {
+				if err = client.UploadPath(filepath.Join(localDir, name), remotePathJoin(remoteDir, name), policy); err != nil {
+					err = fmt.Errorf("failed to copy %s: %w", name, err)
+					break
+				}
+			}
+		}
+
+		return archiveDoneMsg{job: job, err: err}
+	})
+}
+
+// handleExtractHere prompts for the name of a new directory in the current
+// remote directory and extracts the remote archive under the cursor into it,
+// so that nothing already there gets overwritten
+func (m *fileBrowserModel) handleExtractHere() (tea.Model, tea.Cmd) {
+	if m.focusedPanel != RightPanel {
+		return m, nil
+	}
+	file, ok := m.cursorFile(RightPanel)
+	if !ok || file.IsDir || !ssh.IsArchive(file.Name) {
+		return m, nil
+	}
+
+	dir := m.remotePath
+	defaultName := ssh.ArchiveStem(file.Name)
+	if defaultName == "" {
+		defaultName = "extracted"
+	}
+	m.prompt = newPromptModel(fmt.Sprintf("Extract %s into new directory:", file.Name), defaultName, func(value string) tea.Cmd {
+		return extractRemoteCmd(m.sshClient, remotePathJoin(dir, file.Name), remotePathJoin(dir, strings.TrimSpace(value)))
+	})
+	m.prompt.validate = func(value string) error {
+		return validateFileName(strings.TrimSpace(value))
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// extractRemoteCmd creates a command that creates destDir on the server and
+// extracts an archive into it. destDir must not exist yet.
+func extractRemoteCmd(client *ssh.Client, archivePath, destDir string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		if err := client.Mkdir(destDir); err != nil {
+			return errMsg{err}
+		}
+		if err := client.ExtractRemote(archivePath, destDir); err != nil {
+			return errMsg{err}
+		}
+		return fileOpDoneMsg{}
+	})
+}
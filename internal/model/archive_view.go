@@ -0,0 +1,212 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// archiveView shows the inside of an archive in place of a panel's directory listing
+type archiveView struct {
+	archive *ssh.Archive
+	path    string // Location of the archive file itself
+	dir     string // Directory inside the archive, "" for its root
+	files   []ssh.FileInfo
+}
+
+type archiveOpenedMsg struct {
+	side    PanelSide
+	path    string
+	archive *ssh.Archive
+	err     error
+}
+
+// archiveKeys are the keys that work while a panel shows an archive, which is read-only
+var archiveKeys = map[string]bool{
+	"tab": true, "up": true, "k": true, "down": true, "j": true,
+	"enter": true, "left": true, "h": true, "right": true, "l": true,
+	" ": true, "c": true, "v": true, "V": true, "L": true,
+	"s": true, "o": true, "D": true, "/": true, ".": true, "esc": true,
+	"shift+up": true, "shift+down": true, "K": true, "J": true,
+	"a": true, "*": true, "+": true, "-": true, "E": true, "?": true,
+}
+
+// otherPanelKeys are the keys that write into the panel opposite the focused
+// one, and so don't work while that panel shows an archive
+var otherPanelKeys = map[string]bool{
+	"c": true, "m": true, "z": true, "Z": true, "u": true,
+}
+
+// memberPath returns the path inside the archive of an entry of the current directory
+func (v *archiveView) memberPath(name string) string {
+	return path.Join(v.dir, name)
+}
+
+// displayPath returns the location shown in the panel header
+func (v *archiveView) displayPath() string {
+	if v.dir == "" {
+		return v.path
+	}
+	return fmt.Sprintf("%s/%s", v.path, v.dir)
+}
+
+// archiveView returns the archive shown in a panel, or nil when it shows a directory
+func (m *fileBrowserModel) archiveView(side PanelSide) *archiveView {
+	if side == LeftPanel {
+		return m.localArchive
+	}
+	return m.remoteArchive
+}
+
+// handleOpenArchive starts opening the archive under the cursor for browsing
+func (m *fileBrowserModel) handleOpenArchive(file ssh.FileInfo) (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	if side == LeftPanel {
+		return m, openArchiveCmd(m.sshClient, side, filepath.Join(m.localPath, file.Name))
+	}
+	return m, openArchiveCmd(m.sshClient, side, remotePathJoin(m.remotePath, file.Name))
+}
+
+// openArchiveCmd creates a command that reads the table of contents of an archive
+func openArchiveCmd(client *ssh.Client, side PanelSide, archivePath string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		var archive *ssh.Archive
+		var err error
+		if side == LeftPanel {
+			archive, err = ssh.OpenLocalArchive(archivePath)
+		} else {
+			archive, err = client.OpenRemoteArchive(archivePath)
+		}
+		return archiveOpenedMsg{side: side, path: archivePath, archive: archive, err: err}
+	})
+}
+
+// showArchive switches a panel to the root of an opened archive
+func (m *fileBrowserModel) showArchive(msg archiveOpenedMsg) {
+	view := &archiveView{
+		archive: msg.archive,
+		path:    msg.path,
+		files:   m.sortedFiles(msg.side, msg.archive.List("")),
+	}
+	m.resetSelection(msg.side)
+	if msg.side == LeftPanel {
+		m.localArchive = view
+		m.localCursor = 0
+	} else {
+		m.remoteArchive = view
+		m.remoteCursor = 0
+	}
+	m.ensureCursorVisible(msg.side)
+}
+
+// changeArchiveDir moves a panel to another directory inside its archive
+func (m *fileBrowserModel) changeArchiveDir(side PanelSide, dir string) {
+	view := m.archiveView(side)
+	view.dir = dir
+	view.files = m.sortedFiles(side, view.archive.List(dir))
+	m.resetSelection(side)
+	if side == LeftPanel {
+		m.localCursor = 0
+	} else {
+		m.remoteCursor = 0
+	}
+	m.ensureCursorVisible(side)
+}
+
+// handleArchiveEnter enters the directory under the cursor inside an archive,
+// or goes up when the cursor is on ".."
+func (m *fileBrowserModel) handleArchiveEnter() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	if m.cursorFileIndex(side) < 0 {
+		return m.handleArchiveUp()
+	}
+
+	file, _ := m.cursorFile(side)
+	if file.IsDir {
+		m.changeArchiveDir(side, m.archiveView(side).memberPath(file.Name))
+	}
+	return m, nil
+}
+
+// handleArchiveUp goes up one directory inside an archive, leaving it from its root
+func (m *fileBrowserModel) handleArchiveUp() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	view := m.archiveView(side)
+	if view.dir != "" {
+		parent := path.Dir(view.dir)
+		if parent == "." {
+			parent = ""
+		}
+		m.changeArchiveDir(side, parent)
+		return m, nil
+	}
+
+	m.closeArchive(side)
+	if side == LeftPanel {
+		m.localCursor = 0
+	} else {
+		m.remoteCursor = 0
+	}
+	return m, m.loadPanelCmd(side)
+}
+
+// closeArchive stops showing an archive in a panel, if it shows one
+func (m *fileBrowserModel) closeArchive(side PanelSide) {
+	view := m.archiveView(side)
+	if view == nil {
+		return
+	}
+	view.archive.Close()
+	m.resetSelection(side)
+	if side == LeftPanel {
+		m.localArchive = nil
+	} else {
+		m.remoteArchive = nil
+	}
+}
+
+// handleCopyFromArchive copies the targeted archive members to the other panel's directory
+func (m *fileBrowserModel) handleCopyFromArchive() (tea.Model, tea.Cmd) {
+	view := m.archiveView(m.focusedPanel)
+	var members []string
+	for _, file := range m.selectedFiles(m.focusedPanel) {
+		members = append(members, view.memberPath(file.Name))
+	}
+	if len(members) == 0 {
+		return m, nil
+	}
+
+	if m.focusedPanel == LeftPanel {
+		return m, copyFromArchiveCmd(m.sshClient, view.archive, members, RightPanel, m.remotePath)
+	}
+	return m, copyFromArchiveCmd(m.sshClient, view.archive, members, LeftPanel, m.localPath)
+}
+
+// copyFromArchiveCmd creates a command that copies archive members to a
+// directory on one side. The archive is held open for the copy, so leaving it
+// while the copy runs doesn't cut the copy short.
+func copyFromArchiveCmd(client *ssh.Client, archive *ssh.Archive, members []string, dest PanelSide, destDir string) tea.Cmd {
+	release, err := archive.Hold()
+	if err != nil {
+		return func() tea.Msg { return errMsg{fmt.Errorf("failed to copy from archive: %w", err)} }
+	}
+
+	return tea.Cmd(func() tea.Msg {
+		defer release()
+		var err error
+		if dest == LeftPanel {
+			err = archive.CopyToLocal(members, destDir)
+		} else {
+			err = archive.CopyToRemote(client, members, destDir)
+		}
+
+		if err != nil {
+			return errMsg{fmt.Errorf("failed to copy from archive: %w", err)}
+		}
+		return copyCompleteMsg{}
+	})
+}
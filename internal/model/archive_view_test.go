@@ -0,0 +1,136 @@
+package model
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestArchiveKeysOnlyGateArchivePanels(t *testing.T) {
+	m := newTestBrowser(10)
+	m.localArchive = &archiveView{path: "/data/backup.zip", files: m.localFiles}
+
+	// The panel showing the archive is read-only
+	m.focusedPanel = LeftPanel
+	m.moveCursor(LeftPanel, 1)
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if m.mode != modeBrowse {
+		t.Error("Expected delete to be ignored in an archive")
+	}
+
+	// The other panel keeps working, except for writing into the archive
+	m.focusedPanel = RightPanel
+	m.moveCursor(RightPanel, 1)
+	if _, cmd := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")}); cmd != nil {
+		t.Error("Expected moving into an archive to be ignored")
+	}
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if m.mode != modeConfirm {
+		t.Error("Expected delete to work in the panel next to an archive")
+	}
+}
+
+// selectSomething selects a file of a panel, filters it and starts a range selection
+func selectSomething(m *fileBrowserModel, side PanelSide) {
+	m.selection(side).add(ssh.FileInfo{Name: "file000001.log"})
+	m.filter(side).text = "file"
+	m.selectRange = &rangeSelection{side: side, base: newFileSelection()}
+}
+
+// assertNothingSelected fails unless a panel has no selection, range or filter text
+func assertNothingSelected(t *testing.T, m *fileBrowserModel, side PanelSide, when string) {
+	t.Helper()
+	if m.selection(side).len() != 0 || m.filter(side).text != "" || m.selectRange != nil {
+		t.Errorf("Expected the selection and filter to be cleared %s", when)
+	}
+}
+
+func TestArchiveClearsSelection(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "backup.zip")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	if _, err := writer.Create("docs/readme.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	file.Close()
+	archive, err := ssh.OpenLocalArchive(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestBrowser(3)
+	selectSomething(m, LeftPanel)
+	m.showArchive(archiveOpenedMsg{side: LeftPanel, path: zipPath, archive: archive})
+	assertNothingSelected(t, m, LeftPanel, "when entering an archive")
+
+	selectSomething(m, LeftPanel)
+	m.changeArchiveDir(LeftPanel, "docs")
+	assertNothingSelected(t, m, LeftPanel, "when changing directory inside an archive")
+
+	m.changeArchiveDir(LeftPanel, "")
+	selectSomething(m, LeftPanel)
+	m.focusedPanel = LeftPanel
+	m.handleArchiveUp()
+	if m.localArchive != nil {
+		t.Fatal("Expected going up from the root to leave the archive")
+	}
+	assertNothingSelected(t, m, LeftPanel, "when leaving an archive")
+}
+
+func TestLoadOtherDirectoryClearsSelection(t *testing.T) {
+	m := newTestBrowser(3)
+	m.localLoad.shownPath = m.localPath
+
+	// Refreshing the directory shown keeps the selection
+	selectSomething(m, LeftPanel)
+	m.loadPanelCmd(LeftPanel)
+	if m.selection(LeftPanel).len() != 1 || m.filter(LeftPanel).text == "" {
+		t.Error("Expected a refresh to keep the selection and filter")
+	}
+
+	m.localPath = "/elsewhere"
+	m.loadPanelCmd(LeftPanel)
+	assertNothingSelected(t, m, LeftPanel, "when loading another directory")
+}
+
+func TestCopyFromArchiveOutlivesLeaving(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "backup.zip")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	if w, err := writer.Create("readme.txt"); err != nil {
+		t.Fatal(err)
+	} else {
+		w.Write([]byte("hello"))
+	}
+	writer.Close()
+	file.Close()
+	archive, err := ssh.OpenLocalArchive(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestBrowser(1)
+	m.showArchive(archiveOpenedMsg{side: LeftPanel, path: zipPath, archive: archive})
+	dest := t.TempDir()
+	cmd := copyFromArchiveCmd(nil, archive, []string{"readme.txt"}, LeftPanel, dest)
+	m.closeArchive(LeftPanel)
+
+	if msg := cmd(); msg != (copyCompleteMsg{}) {
+		t.Fatalf("Expected the copy to finish after leaving the archive, got %+v", msg)
+	}
+	if content, err := os.ReadFile(filepath.Join(dest, "readme.txt")); err != nil || string(content) != "hello" {
+		t.Errorf("Expected the member to be copied, got %q (%v)", content, err)
+	}
+}
@@ -0,0 +1,262 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// basketItem is a file collected into the basket, with its absolute path on one side
+type basketItem struct {
+	side PanelSide
+	path string
+	info ssh.FileInfo
+}
+
+// basket collects files from any directory on either side for one transfer.
+// Unlike the panel selections it survives changing directories.
+type basket struct {
+	items []basketItem
+}
+
+// add puts a file into the basket unless it is already in it
+func (b *basket) add(item basketItem) {
+	for _, existing := range b.items {
+		if existing.side == item.side && existing.path == item.path {
+			return
+		}
+	}
+	b.items = append(b.items, item)
+}
+
+// remove takes files out of the basket
+func (b *basket) remove(items []basketItem) {
+	kept := b.items[:0]
+	for _, item := range b.items {
+		removed := false
+		for _, other := range items {
+			if other.side == item.side && other.path == item.path {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, item)
+		}
+	}
+	b.items = kept
+}
+
+// basketModel is an overlay listing the files in the basket
+type basketModel struct {
+	basket *basket
+	cursor int
+	err    string
+}
+
+// Basket message types
+type basketTransferMsg struct{}
+
+type basketTransferredMsg struct {
+	items []basketItem // Files that were copied
+	err   error
+}
+
+// newBasketModel creates an overlay showing the contents of a basket
+func newBasketModel(b *basket) *basketModel {
+	return &basketModel{basket: b}
+}
+
+// Init initializes the basket overlay
+func (m *basketModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the basket overlay
+func (m *basketModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = ""
+		items := m.basket.items
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = max(0, min(len(items)-1, m.cursor+1))
+		case "d", "delete":
+			if m.cursor < len(items) {
+				m.basket.remove([]basketItem{items[m.cursor]})
+				m.cursor = max(0, min(len(m.basket.items)-1, m.cursor))
+			}
+		case "X":
+			m.basket.items = nil
+			m.cursor = 0
+		case "c", "enter":
+			if len(items) > 0 {
+				return m, func() tea.Msg { return basketTransferMsg{} }
+			}
+		case "esc", "q", "Y":
+			return m, func() tea.Msg { return dialogCancelledMsg{} }
+		}
+	}
+	return m, nil
+}
+
+// View renders the basket overlay
+func (m *basketModel) View() string {
+	var s strings.Builder
+	s.WriteString(ui.HeaderStyle.Render(fmt.Sprintf("Basket (%d)", len(m.basket.items))) + "\n\n")
+
+	if len(m.basket.items) == 0 {
+		s.WriteString(ui.DimRowStyle.Render("  Empty - press y in a panel to add files") + "\n")
+	}
+	for i, item := range m.basket.items {
+		cursor := "  "
+		style := ui.RegularRowStyle
+		if i == m.cursor {
+			cursor = "> "
+			style = ui.SelectedRowStyle
+		}
+
+		side := "local "
+		if item.side == RightPanel {
+			side = "remote"
+		}
+		name := item.path
+		if item.info.IsDir {
+			name += "/"
+		}
+		s.WriteString(style.Render(fmt.Sprintf("%s%s  %s", cursor, side, name)) + "\n")
+	}
+
+	s.WriteString("\n")
+	if m.err != "" {
+		s.WriteString(ui.ErrorStyle.Render(m.err))
+	} else {
+		s.WriteString(ui.HelpStyle.Render("enter/c: copy all to the other side's directory • d: remove • X: empty • esc: close"))
+	}
+
+	return ui.DialogStyle.Render(s.String())
+}
+
+// handleAddToBasket puts the targeted files of the focused panel into the
+// basket and clears the panel's selection
+func (m *fileBrowserModel) handleAddToBasket() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	for _, file := range m.targetFiles() {
+		item := basketItem{side: side, info: file}
+		if side == LeftPanel {
+			item.path = filepath.Join(m.localPath, file.Name)
+		} else {
+			item.path = remotePathJoin(m.remotePath, file.Name)
+		}
+		m.basket.add(item)
+	}
+	m.selection(side).clear()
+	return m, nil
+}
+
+// handleBasket opens the basket overlay
+func (m *fileBrowserModel) handleBasket() (tea.Model, tea.Cmd) {
+	m.basketView = newBasketModel(m.basket)
+	m.mode = modeBasket
+	return m, m.basketView.Init()
+}
+
+// transferBasket copies every file in the basket into the directory of the
+// panel on the other side, asking first if that would replace existing files.
+// The overlay stays open with an error if that panel shows an archive or two
+// files would be copied to the same name.
+func (m *fileBrowserModel) transferBasket() (tea.Model, tea.Cmd) {
+	items := append([]basketItem(nil), m.basket.items...)
+	for _, item := range items {
+		if m.archiveView(otherPanel(item.side)) != nil {
+			m.basketView.err = "Can't copy into an archive, leave it first"
+			return m, nil
+		}
+	}
+	if err := basketClash(items); err != nil {
+		m.basketView.err = err.Error()
+		return m, nil
+	}
+
+	m.mode = modeBrowse
+	localDir, remoteDir := m.localPath, m.remotePath
+	start := transferBasketCmd(m.sshClient, items, localDir, remoteDir, m.symlinkPolicy)
+	return m, checkBasketOverwriteCmd(m.sshClient, items, localDir, remoteDir, start)
+}
+
+// basketClash returns an error naming two files of the basket that would be
+// copied to the same destination
+func basketClash(items []basketItem) error {
+	type destination struct {
+		side PanelSide
+		name string
+	}
+	seen := make(map[destination]string)
+	for _, item := range items {
+		key := destination{side: otherPanel(item.side), name: item.info.Name}
+		if other, ok := seen[key]; ok {
+			return fmt.Errorf("%s and %s would both be copied as %s, remove one first", other, item.path, item.info.Name)
+		}
+		seen[key] = item.path
+	}
+	return nil
+}
+
+// basketDest returns where a file of the basket is copied to
+func basketDest(item basketItem, localDir, remoteDir string) string {
+	if item.side == LeftPanel {
+		return remotePathJoin(remoteDir, item.info.Name)
+	}
+	return filepath.Join(localDir, item.info.Name)
+}
+
+// checkBasketOverwriteCmd creates a command that looks for existing files at
+// the destinations of a basket transfer on both sides, to confirm replacing
+// them before start runs
+func checkBasketOverwriteCmd(client *ssh.Client, items []basketItem, localDir, remoteDir string, start tea.Cmd) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		dests := make(map[PanelSide][]string)
+		for _, item := range items {
+			dest := otherPanel(item.side)
+			dests[dest] = append(dests[dest], basketDest(item, localDir, remoteDir))
+		}
+
+		var existing []string
+		for _, side := range []PanelSide{LeftPanel, RightPanel} {
+			found, err := existingPaths(client, side, dests[side])
+			if err != nil {
+				return errMsg{err}
+			}
+			existing = append(existing, found...)
+		}
+		return overwriteCheckMsg{action: "Copying the basket", existing: existing, start: start}
+	})
+}
+
+// transferBasketCmd creates a command that uploads the local files of a basket
+// into remoteDir and downloads its remote files into localDir
+func transferBasketCmd(client *ssh.Client, items []basketItem, localDir, remoteDir string, policy ssh.SymlinkPolicy) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		for i, item := range items {
+			var err error
+			if item.side == LeftPanel {
+				err = client.UploadPath(item.path, basketDest(item, localDir, remoteDir), policy)
+			} else {
+				err = client.DownloadPath(item.path, basketDest(item, localDir, remoteDir), policy)
+			}
+
+			if err != nil {
+				// What wasn't copied stays in the basket to retry
+				return basketTransferredMsg{items: items[:i], err: fmt.Errorf("failed to copy %s: %w", item.path, err)}
+			}
+		}
+		return basketTransferredMsg{items: items}
+	})
+}
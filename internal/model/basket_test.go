@@ -0,0 +1,90 @@
+package model
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"sshlepp/internal/ssh"
+)
+
+// newBasketItem creates a basket item for a file at fullPath
+func newBasketItem(side PanelSide, fullPath string) basketItem {
+	return basketItem{side: side, path: fullPath, info: ssh.FileInfo{Name: path.Base(fullPath)}}
+}
+
+func TestBasketAdd(t *testing.T) {
+	var b basket
+	b.add(newBasketItem(LeftPanel, "/data/a.log"))
+	b.add(newBasketItem(LeftPanel, "/data/a.log"))
+	b.add(newBasketItem(RightPanel, "/data/a.log"))
+
+	if len(b.items) != 2 {
+		t.Errorf("Expected a file to be added once per side, got %+v", b.items)
+	}
+}
+
+func TestBasketRemove(t *testing.T) {
+	var b basket
+	for _, item := range []basketItem{
+		newBasketItem(LeftPanel, "/data/a.log"),
+		newBasketItem(LeftPanel, "/data/b.log"),
+		newBasketItem(RightPanel, "/data/a.log"),
+	} {
+		b.add(item)
+	}
+
+	b.remove([]basketItem{newBasketItem(LeftPanel, "/data/a.log"), newBasketItem(RightPanel, "/data/missing")})
+	if len(b.items) != 2 || b.items[0].path != "/data/b.log" || b.items[1].side != RightPanel {
+		t.Errorf("Expected only the local a.log to be removed, got %+v", b.items)
+	}
+}
+
+func TestBasketClash(t *testing.T) {
+	items := []basketItem{
+		newBasketItem(LeftPanel, "/data/one/app.log"),
+		newBasketItem(RightPanel, "/var/log/app.log"),
+	}
+	if err := basketClash(items); err != nil {
+		t.Errorf("Files going to different sides don't clash: %v", err)
+	}
+
+	items = append(items, newBasketItem(LeftPanel, "/data/two/app.log"))
+	if err := basketClash(items); err == nil {
+		t.Error("Expected two local files with the same name to clash")
+	}
+}
+
+func TestTransferBasketRefusesClash(t *testing.T) {
+	m := newTestBrowser(1)
+	m.basket.add(newBasketItem(RightPanel, "/a/app.log"))
+	m.basket.add(newBasketItem(RightPanel, "/b/app.log"))
+	m.handleBasket()
+
+	if _, cmd := m.transferBasket(); cmd != nil || m.mode != modeBasket || m.basketView.err == "" {
+		t.Errorf("Expected the basket to stay open with an error, got mode %v and error %q", m.mode, m.basketView.err)
+	}
+}
+
+func TestTransferBasketConfirmsOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestBrowser(1)
+	m.localPath = dir
+	m.basket.add(newBasketItem(RightPanel, "/var/log/app.log"))
+	m.basket.add(newBasketItem(RightPanel, "/var/log/new.log"))
+	m.handleBasket()
+
+	_, cmd := m.transferBasket()
+	msg, ok := cmd().(overwriteCheckMsg)
+	if !ok || len(msg.existing) != 1 || msg.existing[0] != filepath.Join(dir, "app.log") {
+		t.Fatalf("Expected the existing local file to be reported, got %+v", msg)
+	}
+	m.Update(msg)
+	if m.mode != modeConfirm {
+		t.Errorf("Expected a confirmation before overwriting, got mode %v", m.mode)
+	}
+}
@@ -0,0 +1,218 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sshlepp/internal/config"
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bookmarksModel is a picker listing the bookmarks of the connected host
+type bookmarksModel struct {
+	config *config.Config
+	host   string
+	list   []config.Bookmark
+	cursor int
+	err    string
+}
+
+// Bookmark message types
+type bookmarkJumpMsg struct {
+	bookmark config.Bookmark
+}
+
+type bookmarkCheckedMsg struct {
+	picker   *bookmarksModel
+	bookmark config.Bookmark
+	err      error // Set when a directory of the bookmark no longer exists
+}
+
+type bookmarkAddMsg struct {
+	paired bool // Bookmark both panels' directories together
+}
+
+// newBookmarksModel creates a picker of the bookmarks of a host
+func newBookmarksModel(cfg *config.Config, host string) *bookmarksModel {
+	return &bookmarksModel{
+		config: cfg,
+		host:   host,
+		list:   cfg.Bookmarks(host),
+	}
+}
+
+// Init initializes the bookmark picker
+func (m *bookmarksModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the bookmark picker
+func (m *bookmarksModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = ""
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = max(0, min(len(m.list)-1, m.cursor+1))
+		case "enter", "right", "l":
+			if m.cursor < len(m.list) {
+				jump := bookmarkJumpMsg{bookmark: m.list[m.cursor]}
+				return m, func() tea.Msg { return jump }
+			}
+		case "a":
+			return m, func() tea.Msg { return bookmarkAddMsg{} }
+		case "P":
+			return m, func() tea.Msg { return bookmarkAddMsg{paired: true} }
+		case "d", "delete":
+			if m.cursor < len(m.list) {
+				m.config.RemoveBookmark(m.host, m.list[m.cursor])
+				if err := m.config.Save(); err != nil {
+					m.err = err.Error()
+				}
+				m.list = m.config.Bookmarks(m.host)
+				m.cursor = max(0, min(len(m.list)-1, m.cursor))
+			}
+		case "esc", "q", "b":
+			return m, func() tea.Msg { return dialogCancelledMsg{} }
+		}
+	}
+	return m, nil
+}
+
+// View renders the bookmark picker
+func (m *bookmarksModel) View() string {
+	var s strings.Builder
+	s.WriteString(ui.HeaderStyle.Render("Bookmarks") + "\n\n")
+
+	if len(m.list) == 0 {
+		s.WriteString(ui.DimRowStyle.Render("  No bookmarks yet") + "\n")
+	}
+	for i, bookmark := range m.list {
+		cursor := "  "
+		style := ui.RegularRowStyle
+		if i == m.cursor {
+			cursor = "> "
+			style = ui.SelectedRowStyle
+		}
+
+		var dirs []string
+		if bookmark.LocalDir != "" {
+			dirs = append(dirs, "local: "+bookmark.LocalDir)
+		}
+		if bookmark.RemoteDir != "" {
+			dirs = append(dirs, "remote: "+bookmark.RemoteDir)
+		}
+		s.WriteString(style.Render(fmt.Sprintf("%s%-16s %s", cursor, bookmark.Name, strings.Join(dirs, "  "))) + "\n")
+	}
+
+	s.WriteString("\n")
+	if m.err != "" {
+		s.WriteString(ui.ErrorStyle.Render(m.err))
+	} else {
+		s.WriteString(ui.HelpStyle.Render("enter: jump • a: add current dir • P: add pair of both dirs • d: delete • esc: close"))
+	}
+
+	return ui.DialogStyle.Render(s.String())
+}
+
+// handleBookmarks opens the bookmark picker
+func (m *fileBrowserModel) handleBookmarks() (tea.Model, tea.Cmd) {
+	m.bookmarks = newBookmarksModel(m.config, m.hostName)
+	m.mode = modeBookmarks
+	return m, m.bookmarks.Init()
+}
+
+// handleAddBookmark prompts for the name of a bookmark of the focused panel's
+// directory, or of both panels' directories when paired
+func (m *fileBrowserModel) handleAddBookmark(paired bool) (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	localDir, remoteDir := m.localPath, m.remotePath
+
+	defaultName := filepath.Base(localDir)
+	if side == RightPanel {
+		defaultName = path.Base(remoteDir)
+	}
+
+	title := "Bookmark this directory as:"
+	if paired {
+		title = "Bookmark both directories as:"
+	}
+	m.prompt = newPromptModel(title, defaultName, func(value string) tea.Cmd {
+		bookmark := config.Bookmark{Name: strings.TrimSpace(value)}
+		if paired || side == LeftPanel {
+			bookmark.LocalDir = localDir
+		}
+		if paired || side == RightPanel {
+			bookmark.RemoteDir = remoteDir
+		}
+
+		m.config.AddBookmark(m.hostName, bookmark)
+		if err := m.config.Save(); err != nil {
+			return func() tea.Msg { return errMsg{fmt.Errorf("failed to save bookmark: %w", err)} }
+		}
+		return m.notify.Info(fmt.Sprintf("Bookmarked %s", bookmark.Name))
+	})
+	m.prompt.validate = func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		return nil
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// jumpToBookmark checks the directories of a bookmark still exist before showing them
+func (m *fileBrowserModel) jumpToBookmark(bookmark config.Bookmark) (tea.Model, tea.Cmd) {
+	return m, checkBookmarkCmd(m.sshClient, m.bookmarks, bookmark)
+}
+
+// checkBookmarkCmd creates a command that checks the directories of a bookmark exist
+func checkBookmarkCmd(client *ssh.Client, picker *bookmarksModel, bookmark config.Bookmark) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		msg := bookmarkCheckedMsg{picker: picker, bookmark: bookmark}
+		if bookmark.LocalDir != "" && !isLocalDir(bookmark.LocalDir) {
+			msg.err = fmt.Errorf("%s no longer exists", bookmark.LocalDir)
+		} else if bookmark.RemoteDir != "" {
+			if info, err := client.Stat(bookmark.RemoteDir); err != nil || !info.IsDir {
+				msg.err = fmt.Errorf("%s no longer exists on the server", bookmark.RemoteDir)
+			}
+		}
+		return msg
+	})
+}
+
+// showBookmark shows the directories of a checked bookmark, keeping the picker
+// open with an error if one of them no longer exists
+func (m *fileBrowserModel) showBookmark(msg bookmarkCheckedMsg) (tea.Model, tea.Cmd) {
+	if m.mode != modeBookmarks || msg.picker != m.bookmarks {
+		// The picker was closed while checking
+		return m, nil
+	}
+	if msg.err != nil {
+		m.bookmarks.err = msg.err.Error()
+		return m, nil
+	}
+
+	bookmark := msg.bookmark
+	m.mode = modeBrowse
+	var cmds []tea.Cmd
+	if bookmark.LocalDir != "" {
+		cmds = append(cmds, m.changeDir(LeftPanel, bookmark.LocalDir, ""))
+		m.focusedPanel = LeftPanel
+	}
+	if bookmark.RemoteDir != "" {
+		cmds = append(cmds, m.changeDir(RightPanel, bookmark.RemoteDir, ""))
+		if bookmark.LocalDir == "" {
+			m.focusedPanel = RightPanel
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
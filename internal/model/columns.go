@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// column identifies an optional column shown before file names in the panels
+type column int
+
+const (
+	columnMode column = iota
+	columnOwner
+	columnGroup
+	columnSize
+	columnModTime
+)
+
+// columnNames maps columns to the names used when configuring them
+var columnNames = []string{
+	columnMode:    "mode",
+	columnOwner:   "owner",
+	columnGroup:   "group",
+	columnSize:    "size",
+	columnModTime: "mtime",
+}
+
+// defaultColumns are shown until the user configures the panels
+var defaultColumns = []column{columnSize}
+
+// parseColumns parses a comma separated list of column names
+func parseColumns(spec string) ([]column, error) {
+	var columns []column
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for col, colName := range columnNames {
+			if colName == name {
+				columns = append(columns, column(col))
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(columnNames, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// formatColumnSpec formats columns as a comma separated list of names
+func formatColumnSpec(columns []column) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = columnNames[col]
+	}
+	return strings.Join(names, ",")
+}
+
+// renderColumns renders the configured columns for a file as a fixed-width prefix
+func renderColumns(columns []column, file ssh.FileInfo) string {
+	var s strings.Builder
+	for _, col := range columns {
+		switch col {
+		case columnMode:
+			s.WriteString(fmt.Sprintf("%-10s ", file.Mode.String()))
+		case columnOwner:
+			s.WriteString(fmt.Sprintf("%-8.8s ", file.Owner))
+		case columnGroup:
+			s.WriteString(fmt.Sprintf("%-8.8s ", file.Group))
+		case columnSize:
+			s.WriteString(fmt.Sprintf("%6s ", formatSize(file.Size)))
+		case columnModTime:
+			s.WriteString(fmt.Sprintf("%-12s ", formatModTime(file.ModTime)))
+		}
+	}
+	return s.String()
+}
+
+// formatSize formats a size in bytes using binary units
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// formatModTime formats a modification time like ls -l does
+func formatModTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if time.Since(t) > 180*24*time.Hour || t.After(time.Now()) {
+		return t.Format("Jan _2  2006")
+	}
+	return t.Format("Jan _2 15:04")
+}
+
+// handleColumns prompts for the columns to show in both panels
+func (m *fileBrowserModel) handleColumns() (tea.Model, tea.Cmd) {
+	title := fmt.Sprintf("Columns (%s):", strings.Join(columnNames, ", "))
+	m.prompt = newPromptModel(title, formatColumnSpec(m.columns), func(value string) tea.Cmd {
+		m.columns, _ = parseColumns(value)
+		return nil
+	})
+	m.prompt.validate = func(value string) error {
+		_, err := parseColumns(value)
+		return err
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
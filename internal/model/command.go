@@ -0,0 +1,196 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// commandOutputModel shows the output and exit status of a remote command
+type commandOutputModel struct {
+	command  string
+	viewport viewport.Model
+	result   ssh.CommandResult
+	running  bool
+	stopped  bool // Stopped before it finished, result holds the output so far
+	cancel   context.CancelFunc
+	err      error
+}
+
+type commandDoneMsg struct {
+	output *commandOutputModel
+	result ssh.CommandResult
+	err    error
+}
+
+// newCommandOutputModel creates the result pane for a command that is
+// starting. cancel stops the command.
+func newCommandOutputModel(command string, cancel context.CancelFunc, width, height int) *commandOutputModel {
+	return &commandOutputModel{
+		command:  command,
+		viewport: viewport.New(width, height),
+		running:  true,
+		cancel:   cancel,
+	}
+}
+
+// Init initializes the result pane
+func (m *commandOutputModel) Init() tea.Cmd {
+	return nil
+}
+
+// setSize resizes the result pane
+func (m *commandOutputModel) setSize(width, height int) {
+	m.viewport.Width = width
+	m.viewport.Height = height
+}
+
+// Update handles messages for the result pane
+func (m *commandOutputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case commandDoneMsg:
+		m.running = false
+		m.result = msg.result
+		m.err = msg.err
+		if errors.Is(msg.err, context.Canceled) {
+			m.stopped, m.err = true, nil
+		}
+		m.viewport.SetContent(m.renderOutput())
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			if m.running {
+				// First esc stops the command, keeping its output so far
+				m.cancel()
+				return m, nil
+			}
+			return m, func() tea.Msg { return dialogCancelledMsg{} }
+		case "q":
+			m.cancel()
+			return m, func() tea.Msg { return dialogCancelledMsg{} }
+		}
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// renderOutput renders stdout followed by stderr, with stderr in red
+func (m *commandOutputModel) renderOutput() string {
+	var lines []string
+	for _, line := range outputLines(m.result.Stdout) {
+		lines = append(lines, ui.SanitizeLine(line))
+	}
+	for _, line := range outputLines(m.result.Stderr) {
+		lines = append(lines, ui.StderrStyle.Render(ui.SanitizeLine(line)))
+	}
+	if m.result.Truncated {
+		lines = append(lines, ui.DimRowStyle.Render("--- output truncated ---"))
+	}
+	if len(lines) == 0 {
+		return ui.DimRowStyle.Render("(no output)")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// outputLines splits command output into lines, ignoring the final newline
+func outputLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// View renders the result pane
+func (m *commandOutputModel) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252")).
+		Bold(true).
+		Padding(0, 1)
+	title := titleStyle.Render(fmt.Sprintf("$ %s", m.command))
+	header := lipgloss.JoinHorizontal(lipgloss.Center, title,
+		strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title))))
+
+	var status, content string
+	switch {
+	case m.running:
+		status = "running"
+		content = ui.DimRowStyle.Render("Running...")
+	case m.err != nil:
+		status = "failed"
+		content = ui.ErrorStyle.Render(fmt.Sprintf("Error: %s", m.err.Error()))
+	case m.stopped:
+		status = "stopped"
+		content = m.viewport.View()
+	default:
+		status = fmt.Sprintf("exit status %d", m.result.ExitStatus)
+		content = m.viewport.View()
+	}
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Padding(0, 1)
+	if !m.running && (m.err != nil || m.result.ExitStatus != 0) {
+		statusStyle = statusStyle.Foreground(lipgloss.Color("#FF0000"))
+	}
+	statusView := statusStyle.Render(status)
+	footer := lipgloss.JoinHorizontal(lipgloss.Center,
+		strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(statusView))), statusView)
+
+	help := ui.HelpStyle.Render("↑/↓/PgUp/PgDn: scroll • esc/q: close")
+	if m.running {
+		help = ui.HelpStyle.Render("esc: stop • q: stop and close")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, header, content, footer, help)
+}
+
+// handleRunCommand prompts for a remote command to run in the current remote directory
+func (m *fileBrowserModel) handleRunCommand() (tea.Model, tea.Cmd) {
+	dir := m.remotePath
+	var files []string
+	for _, file := range m.selectedFiles(RightPanel) {
+		files = append(files, file.Name)
+	}
+	if len(files) == 0 && m.focusedPanel == RightPanel {
+		if file, ok := m.cursorFile(RightPanel); ok {
+			files = append(files, file.Name)
+		}
+	}
+
+	m.prompt = newPromptModel("Run on remote (%d: directory, %f: selected files):", "", func(value string) tea.Cmd {
+		command := ssh.ExpandCommand(value, dir, files)
+		ctx, cancel := context.WithCancel(context.Background())
+		m.output = newCommandOutputModel(command, cancel, m.width-2, m.height-4)
+		m.mode = modeCommandOutput
+		return runCommandCmd(ctx, m.sshClient, m.output, dir, command)
+	})
+	m.prompt.validate = func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("command cannot be empty")
+		}
+		return nil
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// runCommandCmd creates a command that runs a remote command and collects
+// its output until it finishes or ctx is cancelled
+func runCommandCmd(ctx context.Context, client *ssh.Client, output *commandOutputModel, dir, command string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		result, err := client.RunCommandContext(ctx, dir, command)
+		return commandDoneMsg{output: output, result: result, err: err}
+	})
+}
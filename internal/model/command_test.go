@@ -0,0 +1,46 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCommandOutputEscStopsThenCloses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	output := newCommandOutputModel("sleep 600", cancel, 80, 10)
+
+	_, cmd := output.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd != nil || ctx.Err() == nil {
+		t.Fatal("Expected the first esc to stop the command and keep the pane open")
+	}
+
+	output.Update(commandDoneMsg{output: output, result: ssh.CommandResult{Stdout: []byte("partial\n")}, err: ctx.Err()})
+	if view := output.View(); !strings.Contains(view, "stopped") || !strings.Contains(view, "partial") {
+		t.Errorf("Expected the stopped status and the output so far, got %q", view)
+	}
+
+	_, cmd = output.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("Expected esc to close a finished command")
+	}
+	if _, ok := cmd().(dialogCancelledMsg); !ok {
+		t.Error("Expected closing to cancel the dialog")
+	}
+}
+
+func TestCommandOutputQuitStopsAndCloses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	output := newCommandOutputModel("sleep 600", cancel, 80, 10)
+
+	_, cmd := output.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cmd == nil || ctx.Err() == nil {
+		t.Fatal("Expected q to stop the command and close the pane")
+	}
+}
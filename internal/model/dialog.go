@@ -0,0 +1,224 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"sshlepp/internal/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// browserMode represents what the file browser is currently doing with key input
+type browserMode int
+
+const (
+	modeBrowse browserMode = iota
+	modePrompt
+	modeConfirm
+	modePermissions
+	modePager
+	modeTail
+	modeCommandOutput
+	modeFind
+	modeGrep
+	modeBookmarks
+	modeBasket
+	modeErrorLog
+	modeHelp
+)
+
+// promptModel is an inline single-line text input shown below the panels
+type promptModel struct {
+	title     string
+	textInput textinput.Model
+	onSubmit  func(value string) tea.Cmd
+	validate  func(value string) error
+	complete  func(value string) (string, []string) // Tab completion, returning the candidates when ambiguous. Runs in a command.
+	onChange  func(value string)                    // Called as the value is typed
+	onCancel  func()
+	err       string
+	hint      string
+}
+
+// Prompt message types
+type promptSubmittedMsg struct {
+	value string
+}
+
+type promptCompletedMsg struct {
+	prompt     *promptModel
+	value      string // Value that was completed
+	completed  string
+	candidates []string
+}
+
+type dialogCancelledMsg struct{}
+
+// newPromptModel creates a prompt pre-filled with value
+func newPromptModel(title, value string, onSubmit func(value string) tea.Cmd) *promptModel {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.SetValue(value)
+	ti.CursorEnd()
+	ti.Focus()
+	ti.Width = 50
+
+	return &promptModel{
+		title:     title,
+		textInput: ti,
+		onSubmit:  onSubmit,
+	}
+}
+
+// Init initializes the prompt
+func (m *promptModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles messages for the prompt
+func (m *promptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case promptCompletedMsg:
+		// Completions that arrive after the value was edited further are dropped
+		if msg.prompt == m && msg.value == m.textInput.Value() {
+			m.textInput.SetValue(msg.completed)
+			m.textInput.CursorEnd()
+			m.err = ""
+			m.hint = strings.Join(msg.candidates, "  ")
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			value := m.textInput.Value()
+			if m.validate != nil {
+				if err := m.validate(value); err != nil {
+					// Keep the prompt open so the value can be corrected
+					m.err = err.Error()
+					return m, nil
+				}
+			}
+			return m, func() tea.Msg {
+				return promptSubmittedMsg{value: value}
+			}
+		case tea.KeyEscape:
+			if m.onCancel != nil {
+				m.onCancel()
+			}
+			return m, func() tea.Msg {
+				return dialogCancelledMsg{}
+			}
+		case tea.KeyTab:
+			if m.complete != nil {
+				// Completing may list a remote directory, so it happens off the UI loop
+				value, complete := m.textInput.Value(), m.complete
+				return m, func() tea.Msg {
+					completed, candidates := complete(value)
+					return promptCompletedMsg{prompt: m, value: value, completed: completed, candidates: candidates}
+				}
+			}
+		}
+	}
+
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.err = ""
+		m.hint = ""
+	}
+	previous := m.textInput.Value()
+	m.textInput, cmd = m.textInput.Update(msg)
+	if m.onChange != nil && m.textInput.Value() != previous {
+		m.onChange(m.textInput.Value())
+	}
+	return m, cmd
+}
+
+// View renders the prompt
+func (m *promptModel) View() string {
+	help := "enter: confirm • esc: cancel"
+	if m.complete != nil {
+		help = "tab: complete • " + help
+	}
+	help = ui.HelpStyle.Render(help)
+	switch {
+	case m.err != "":
+		help = ui.ErrorStyle.Render(m.err)
+	case m.hint != "":
+		help = ui.HelpStyle.Render(m.hint)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		ui.HeaderStyle.Render(m.title),
+		m.textInput.View(),
+		help,
+	)
+}
+
+// confirmModel is a yes/no dialog listing the items an action will affect
+type confirmModel struct {
+	title     string
+	items     []string
+	onConfirm func() tea.Cmd
+}
+
+type confirmAcceptedMsg struct{}
+
+// maxConfirmItems limits how many items the confirmation dialog lists
+const maxConfirmItems = 15
+
+// newConfirmModel creates a new confirmation dialog
+func newConfirmModel(title string, items []string, onConfirm func() tea.Cmd) *confirmModel {
+	return &confirmModel{
+		title:     title,
+		items:     items,
+		onConfirm: onConfirm,
+	}
+}
+
+// Init initializes the confirmation dialog
+func (m *confirmModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the confirmation dialog
+func (m *confirmModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y", "enter":
+			return m, func() tea.Msg {
+				return confirmAcceptedMsg{}
+			}
+		case "n", "N", "esc":
+			return m, func() tea.Msg {
+				return dialogCancelledMsg{}
+			}
+		}
+	}
+	return m, nil
+}
+
+// View renders the confirmation dialog
+func (m *confirmModel) View() string {
+	var s strings.Builder
+	s.WriteString(ui.HeaderStyle.Render(m.title) + "\n\n")
+
+	for i, item := range m.items {
+		if i == maxConfirmItems {
+			more := fmt.Sprintf("  … and %d more", len(m.items)-maxConfirmItems)
+			s.WriteString(ui.DimRowStyle.Render(more) + "\n")
+			break
+		}
+		s.WriteString(ui.RegularRowStyle.Render("  "+item) + "\n")
+	}
+
+	s.WriteString(ui.HelpStyle.Render("y/enter: confirm • n/esc: cancel"))
+
+	return ui.DialogStyle.Render(s.String())
+}
@@ -0,0 +1,226 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editSession tracks a remote file downloaded for editing
+type editSession struct {
+	remotePath string
+	localPath  string
+	tempDir    string
+	original   ssh.FileInfo // Remote file as it was when downloaded
+	checksum   [sha256.Size]byte
+}
+
+// Edit message types
+type editReadyMsg struct {
+	session *editSession
+}
+
+type editorFinishedMsg struct {
+	session *editSession
+	err     error
+}
+
+type editConflictMsg struct {
+	session *editSession
+}
+
+type editorFailedMsg struct {
+	session *editSession
+	err     error
+}
+
+// handleEdit opens the file under the cursor in $EDITOR. Local files are
+// edited in place; remote files are downloaded first and uploaded on save.
+func (m *fileBrowserModel) handleEdit() (tea.Model, tea.Cmd) {
+	file, ok := m.cursorFile(m.focusedPanel)
+	if !ok || file.IsDir {
+		return m, nil
+	}
+
+	if m.focusedPanel == LeftPanel {
+		localPath := filepath.Join(m.localPath, file.Name)
+		return m, tea.ExecProcess(editorCommand(localPath), func(err error) tea.Msg {
+			if err != nil {
+				return errMsg{fmt.Errorf("editor failed: %w", err)}
+			}
+			return fileOpDoneMsg{}
+		})
+	}
+
+	return m, downloadForEditCmd(m.sshClient, remotePathJoin(m.remotePath, file.Name))
+}
+
+// editorCommand builds the command that edits path, honouring $VISUAL and $EDITOR
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// Allow editors configured with arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// fileChecksum returns the SHA-256 of a local file
+func fileChecksum(path string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	file, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return sum, err
+	}
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
+
+// downloadForEditCmd creates a command to download a remote file into a private temp directory
+func downloadForEditCmd(client *ssh.Client, remotePath string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		original, err := client.Stat(remotePath)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		// MkdirTemp creates the directory with mode 0700 so only we can read the copy
+		tempDir, err := os.MkdirTemp("", "sshlepp-edit-")
+		if err != nil {
+			return errMsg{fmt.Errorf("failed to create temp directory: %w", err)}
+		}
+
+		// Keep the original name so the editor can detect the file type
+		localPath := filepath.Join(tempDir, filepath.Base(remotePath))
+		if err := client.CopyFileToLocal(remotePath, localPath); err != nil {
+			os.RemoveAll(tempDir)
+			return errMsg{err}
+		}
+
+		checksum, err := fileChecksum(localPath)
+		if err != nil {
+			os.RemoveAll(tempDir)
+			return errMsg{fmt.Errorf("failed to read downloaded file: %w", err)}
+		}
+
+		return editReadyMsg{session: &editSession{
+			remotePath: remotePath,
+			localPath:  localPath,
+			tempDir:    tempDir,
+			original:   original,
+			checksum:   checksum,
+		}}
+	})
+}
+
+// runEditorCmd suspends the TUI and runs the editor on the downloaded copy
+func runEditorCmd(session *editSession) tea.Cmd {
+	return tea.ExecProcess(editorCommand(session.localPath), func(err error) tea.Msg {
+		return editorFinishedMsg{session: session, err: err}
+	})
+}
+
+// editorFailedCmd creates a command that checks whether the copy was changed
+// by an editor that exited with an error. Editors such as vim do that after
+// errors during a session, so saved edits are kept rather than thrown away.
+func editorFailedCmd(session *editSession, editorErr error) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		checksum, err := fileChecksum(session.localPath)
+		if err != nil {
+			return errMsg{fmt.Errorf("editor failed, edited copy kept at %s: %w", session.localPath, editorErr)}
+		}
+		if checksum == session.checksum {
+			os.RemoveAll(session.tempDir)
+			return errMsg{fmt.Errorf("editor failed: %w", editorErr)}
+		}
+		return editorFailedMsg{session: session, err: editorErr}
+	})
+}
+
+// finishEditCmd creates a command that uploads the edited copy if it changed,
+// unless the remote file was modified while it was being edited
+func finishEditCmd(client *ssh.Client, session *editSession) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		checksum, err := fileChecksum(session.localPath)
+		if err != nil {
+			return errMsg{fmt.Errorf("failed to read edited file: %w", err)}
+		}
+		if checksum == session.checksum {
+			os.RemoveAll(session.tempDir)
+			return nil
+		}
+
+		current, err := client.Stat(session.remotePath)
+		if err == nil && (current.Size != session.original.Size || !current.ModTime.Equal(session.original.ModTime)) {
+			return editConflictMsg{session: session}
+		}
+
+		return uploadEditCmd(client, session)()
+	})
+}
+
+// uploadEditCmd creates a command that atomically replaces the remote file with the edited copy
+func uploadEditCmd(client *ssh.Client, session *editSession) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		if err := client.UploadAtomic(session.localPath, session.remotePath, session.original.Mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return errMsg{fmt.Errorf("failed to upload %s, edited copy kept at %s: %w",
+				session.remotePath, session.localPath, err)}
+		}
+
+		os.RemoveAll(session.tempDir)
+		return fileOpDoneMsg{}
+	})
+}
+
+// confirmEditConflict asks whether to overwrite a remote file that changed during editing
+func (m *fileBrowserModel) confirmEditConflict(session *editSession) (tea.Model, tea.Cmd) {
+	title := fmt.Sprintf("%s changed on the server while you were editing. Overwrite it?", session.remotePath)
+	items := []string{
+		fmt.Sprintf("Your edited copy is kept at %s if you cancel", session.localPath),
+	}
+
+	m.confirm = newConfirmModel(title, items, func() tea.Cmd {
+		return uploadEditCmd(m.sshClient, session)
+	})
+	m.mode = modeConfirm
+	return m, m.confirm.Init()
+}
+
+// confirmFailedEdit asks whether to upload a copy changed by an editor that
+// exited with an error
+func (m *fileBrowserModel) confirmFailedEdit(msg editorFailedMsg) (tea.Model, tea.Cmd) {
+	title := fmt.Sprintf("The editor exited with an error (%s). Upload your changes to %s?", msg.err, msg.session.remotePath)
+	items := []string{
+		fmt.Sprintf("Your edited copy is kept at %s if you cancel", msg.session.localPath),
+	}
+
+	m.confirm = newConfirmModel(title, items, func() tea.Cmd {
+		return finishEditCmd(m.sshClient, msg.session)
+	})
+	m.mode = modeConfirm
+	return m, m.confirm.Init()
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newEditSession creates a session for a downloaded copy holding content
+func newEditSession(t *testing.T, content string) *editSession {
+	t.Helper()
+	tempDir := t.TempDir()
+	localPath := filepath.Join(tempDir, "app.conf")
+	if err := os.WriteFile(localPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := fileChecksum(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &editSession{remotePath: "/etc/app.conf", localPath: localPath, tempDir: tempDir, checksum: checksum}
+}
+
+func TestEditorFailedUnchanged(t *testing.T) {
+	session := newEditSession(t, "port = 22\n")
+	if _, ok := editorFailedCmd(session, errors.New("exit status 1"))().(errMsg); !ok {
+		t.Error("Expected the editor error to be reported")
+	}
+	if _, err := os.Stat(session.tempDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the unchanged copy to be removed, got %v", err)
+	}
+}
+
+func TestEditorFailedKeepsChanges(t *testing.T) {
+	session := newEditSession(t, "port = 22\n")
+	if err := os.WriteFile(session.localPath, []byte("port = 2222\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := editorFailedCmd(session, errors.New("exit status 1"))().(editorFailedMsg)
+	if !ok {
+		t.Fatal("Expected the changed copy to be offered for upload")
+	}
+	m := newTestBrowser(1)
+	m.Update(msg)
+	if m.mode != modeConfirm {
+		t.Errorf("Expected a confirmation before uploading, got mode %v", m.mode)
+	}
+	if _, err := os.Stat(session.localPath); err != nil {
+		t.Errorf("Expected the edited copy to be kept: %v", err)
+	}
+}
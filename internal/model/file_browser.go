@@ -1,16 +1,18 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
+	"sshlepp/internal/config"
 	"sshlepp/internal/ssh"
 	"sshlepp/internal/ui"
 
-	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -29,98 +31,109 @@ type fileBrowserModel struct {
 	remoteFiles    []ssh.FileInfo
 	localCursor    int
 	remoteCursor   int
-	localSelected  map[int]bool
-	remoteSelected map[int]bool
+	localSelected  *fileSelection
+	remoteSelected *fileSelection
+	selectRange    *rangeSelection // Range being selected with shift, nil when none
 	focusedPanel   PanelSide
 	localPath      string
 	remotePath     string
-	sshClient      *ssh.Client
-	width, height  int
-	err            error
-	ready          bool
-	leftViewport   viewport.Model
-	rightViewport  viewport.Model
+	remoteHome     string // Where the session started, used to expand ~
+	localHistory   dirHistory
+	remoteHistory  dirHistory
+	config         *config.Config
+	hostName       string // SSH config alias of the connected host
+	// Bumped on every location change, so only the last scheduled save runs
+	locationGeneration int
+	locationUnsaved    bool // The remembered location hasn't been saved yet
+	localSort          ssh.SortOrder
+	remoteSort         ssh.SortOrder
+	localFilter        panelFilter
+	remoteFilter       panelFilter
+	localListing       panelListing // Cache of the files listed through the filter
+	remoteListing      panelListing
+	localLoad          panelLoadState
+	remoteLoad         panelLoadState
+	spinner            spinner.Model // Shown in panels while they load
+	sshClient          *ssh.Client
+	width, height      int
+	notify             *ui.Notifier
+	ready              bool
+	leftView           panelView
+	rightView          panelView
+	mode               browserMode
+	prompt             *promptModel
+	confirm            *confirmModel
+	permissions        *permissionsModel
+	columns            []column
+	symlinkPolicy      ssh.SymlinkPolicy
+	showPreview        bool
+	preview            *previewModel
+	tail               *tailModel
+	output             *commandOutputModel
+	archive            *archiveJob
+	localArchive       *archiveView
+	remoteArchive      *archiveView
+	find               *findModel
+	grep               *grepModel
+	bookmarks          *bookmarksModel
+	basket             *basket // Kept across directory changes, unlike the selections
+	basketView         *basketModel
+	// Files to put the cursor on once the next listing has loaded
+	localCursorName  string
+	remoteCursorName string
 }
 
 // Messages
-type loadFilesMsg struct {
-	localFiles  []ssh.FileInfo
-	remoteFiles []ssh.FileInfo
-	err         error
-}
-
 type errMsg struct {
 	err error
 }
 
 // newFileBrowserModel creates a new file browser model with an existing SSH client
-func newFileBrowserModel(client *ssh.Client, host *ssh.SSHHost, width, height int) (*fileBrowserModel, tea.Cmd) {
+func newFileBrowserModel(client *ssh.Client, host *ssh.SSHHost, cfg *config.Config, notify *ui.Notifier, width, height int) (*fileBrowserModel, tea.Cmd) {
 	// Get current working directory
 	localPath, err := os.Getwd()
 	if err != nil {
 		localPath = "."
 	}
 
+	// Start in the directory the server puts the session in, which for
+	// chrooted accounts may be the only one that can be listed
+	remoteHome := client.HomeDir()
+
+	// Return to where the last session with this host left off
+	state := cfg.Host(host.Name)
+	localPath = restoreLocation(state.LocalDir, localPath, isLocalDir)
+	remotePath := restoreLocation(state.RemoteDir, remoteHome, func(dir string) bool {
+		info, err := client.Stat(dir)
+		return err == nil && info.IsDir
+	})
+
 	model := &fileBrowserModel{
-		localSelected:  make(map[int]bool),
-		remoteSelected: make(map[int]bool),
+		localSelected:  newFileSelection(),
+		remoteSelected: newFileSelection(),
+		basket:         &basket{},
 		focusedPanel:   LeftPanel,
+		columns:        defaultColumns,
 		localPath:      localPath,
-		remotePath:     "/",    // Start at root for remote
+		remotePath:     remotePath,
+		remoteHome:     remoteHome,
+		config:         cfg,
+		hostName:       host.Name,
+		localSort:      sortOrderFromConfig(cfg.LocalSort),
+		remoteSort:     sortOrderFromConfig(cfg.RemoteSort),
+		spinner:        newLoadSpinner(),
 		sshClient:      client, // Use the provided client
+		notify:         notify,
 		width:          width,
 		height:         height,
 		ready:          false,
 	}
 
-	// Initialize viewports immediately since we have width and height
-	model.initializeViewports()
+	// Size the panels immediately since we have width and height
+	model.resizePanels()
 
 	// Load initial files since we already have the SSH client
-	return model, loadFilesCmd(model)
-}
-
-// initializeViewports initializes the viewports with current dimensions
-func (m *fileBrowserModel) initializeViewports() {
-	headerHeight := 3                                         // Header with path info
-	footerHeight := 2                                         // Footer with scroll info
-	panelWidth := (m.width - 4) / 2                           // Account for borders and spacing
-	panelHeight := m.height - headerHeight - footerHeight - 4 // Account for help text
-
-	// Initialize viewports
-	m.leftViewport = viewport.New(panelWidth-2, panelHeight) // Account for padding
-	m.rightViewport = viewport.New(panelWidth-2, panelHeight)
-	m.ready = true
-
-	// Set initial content if files are already loaded
-	if len(m.localFiles) > 0 || len(m.remoteFiles) > 0 {
-		m.updateViewportContent()
-	}
-}
-
-// Commands
-func loadFilesCmd(m *fileBrowserModel) tea.Cmd {
-	return tea.Cmd(func() tea.Msg {
-		// Load local files
-		localFiles, localErr := ssh.ListLocalDir(m.localPath)
-		if localErr != nil {
-			return errMsg{localErr}
-		}
-
-		var remoteFiles []ssh.FileInfo
-		var remoteErr error
-		if m.sshClient != nil {
-			remoteFiles, remoteErr = m.sshClient.ListDir(m.remotePath)
-			if remoteErr != nil {
-				return errMsg{remoteErr}
-			}
-		}
-
-		return loadFilesMsg{
-			localFiles:  localFiles,
-			remoteFiles: remoteFiles,
-		}
-	})
+	return model, model.loadPanelsCmd()
 }
 
 // Init initializes the file browser
@@ -130,190 +143,248 @@ func (m *fileBrowserModel) Init() tea.Cmd {
 
 // Update handles messages
 func (m *fileBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var (
-		cmd  tea.Cmd
-		cmds []tea.Cmd
-	)
+	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
-	case loadFilesMsg:
-		m.localFiles = msg.localFiles
-		m.remoteFiles = msg.remoteFiles
-		if msg.err != nil {
-			m.err = msg.err
+	case panelFilesMsg:
+		return m, m.receiveFiles(msg)
+
+	case spinner.TickMsg:
+		if !m.loading() {
+			return m, nil // Let the spinner stop
 		}
-		// Clear selections when loading new files (changing directories)
-		m.localSelected = make(map[int]bool)
-		m.remoteSelected = make(map[int]bool)
-		// Update viewport content after loading files
-		if m.ready {
-			m.updateViewportContent()
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case tailTickMsg:
+		if m.mode == modeTail && msg.tail == m.tail {
+			return m.tail.Update(msg)
 		}
 		return m, nil
 
-	case errMsg:
-		m.err = msg.err
+	case tailDataMsg:
+		if m.mode == modeTail && msg.tail == m.tail {
+			return m.tail.Update(msg)
+		}
 		return m, nil
 
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-
-		if !m.ready {
-			// Initialize viewports if not ready yet
-			m.initializeViewports()
-			m.updateViewportContent()
-		} else {
-			// Resize existing viewports
-			headerHeight := 3                                         // Header with path info
-			footerHeight := 2                                         // Footer with scroll info
-			panelWidth := (m.width - 4) / 2                           // Account for borders and spacing
-			panelHeight := m.height - headerHeight - footerHeight - 4 // Account for help text
-
-			m.leftViewport.Width = panelWidth - 2
-			m.leftViewport.Height = panelHeight
-			m.rightViewport.Width = panelWidth - 2
-			m.rightViewport.Height = panelHeight
+	case archiveProgressMsg:
+		if msg.job != m.archive {
+			return m, nil
 		}
+		m.archive.bytes = msg.bytes
+		m.archive.fellBack = msg.fellBack
+		return m, waitForArchiveCmd(m.archive)
 
-	case tea.KeyMsg:
-		return m.handleKeyPress(msg)
-	}
-
-	// Handle viewport updates for scrolling
-	if m.ready {
-		if m.focusedPanel == LeftPanel {
-			m.leftViewport, cmd = m.leftViewport.Update(msg)
-		} else {
-			m.rightViewport, cmd = m.rightViewport.Update(msg)
+	case archiveDoneMsg:
+		if msg.job == m.archive {
+			m.archive = nil
 		}
-		cmds = append(cmds, cmd)
-	}
+		var skipped *ssh.SkippedEntriesError
+		if errors.As(msg.err, &skipped) {
+			return m, tea.Batch(m.notify.Warn(skipped.Error()), m.loadPanelsCmd())
+		}
+		if msg.err != nil {
+			return m, tea.Batch(m.notify.Error(msg.err), m.loadPanelsCmd())
+		}
+		return m, m.loadPanelsCmd()
 
-	return m, tea.Batch(cmds...)
-}
+	case locationSaveMsg:
+		return m, m.handleLocationSave(msg)
 
-// updateViewportContent updates the content of both viewports
-func (m *fileBrowserModel) updateViewportContent() {
-	if !m.ready {
-		return
-	}
+	case bookmarkJumpMsg:
+		return m.jumpToBookmark(msg.bookmark)
 
-	m.leftViewport.SetContent(m.generatePanelContent(LeftPanel))
-	m.rightViewport.SetContent(m.generatePanelContent(RightPanel))
-}
+	case bookmarkCheckedMsg:
+		return m.showBookmark(msg)
 
-// ensureCursorVisible scrolls the viewport to ensure the cursor is visible
-func (m *fileBrowserModel) ensureCursorVisible(side PanelSide) {
-	if !m.ready {
-		return
-	}
+	case goToCheckedMsg:
+		return m.finishGoTo(msg)
 
-	var cursor int
-	var viewport *viewport.Model
+	case bookmarkAddMsg:
+		return m.handleAddBookmark(msg.paired)
 
-	if side == LeftPanel {
-		cursor = m.localCursor
-		viewport = &m.leftViewport
-	} else {
-		cursor = m.remoteCursor
-		viewport = &m.rightViewport
-	}
-
-	// Calculate line position (cursor + header lines)
-	linePos := cursor + 2 // Account for header lines
+	case basketTransferMsg:
+		return m.transferBasket()
 
-	// Scroll to make cursor visible
-	if linePos < viewport.YOffset {
-		viewport.YOffset = linePos
-	} else if linePos >= viewport.YOffset+viewport.Height {
-		viewport.YOffset = linePos - viewport.Height + 1
-	}
-}
+	case basketTransferredMsg:
+		m.basket.remove(msg.items)
+		if msg.err != nil {
+			return m, tea.Batch(m.notify.Error(msg.err), m.loadPanelsCmd())
+		}
+		return m, tea.Batch(m.notify.Info(fmt.Sprintf("Copied %d item(s) from the basket", len(msg.items))), m.loadPanelsCmd())
 
-// generatePanelContent generates the content for a panel that will be displayed in a viewport
-func (m *fileBrowserModel) generatePanelContent(side PanelSide) string {
-	var files []ssh.FileInfo
-	var cursor int
-	var selected map[int]bool
-	var path string
-	var title string
+	case resultsMsg[ssh.FindResult]:
+		if m.find == nil || msg.list != m.find.resultList {
+			return m, nil
+		}
+		return m.find.Update(msg)
 
-	if side == LeftPanel {
-		files = m.localFiles
-		cursor = m.localCursor
-		selected = m.localSelected
-		path = m.localPath
-		title = "Local"
-	} else {
-		files = m.remoteFiles
-		cursor = m.remoteCursor
-		selected = m.remoteSelected
-		path = m.remotePath
-		title = "Remote"
-	}
+	case resultsMsg[ssh.GrepMatch]:
+		if m.grep == nil || msg.list != m.grep.resultList {
+			return m, nil
+		}
+		return m.grep.Update(msg)
 
-	var content strings.Builder
-	content.WriteString(fmt.Sprintf("%s: %s\n\n", title, path))
+	case findJumpMsg:
+		return m.jumpToFindResult(msg)
 
-	// Add .. entry if not at root
-	if (side == LeftPanel && !isLocalRoot(m.localPath)) || (side == RightPanel && m.remotePath != "/") {
-		cursorIcon := " "
-		if cursor == 0 {
-			cursorIcon = ">"
-		}
-		style := ui.RegularRowStyle
-		if cursor == 0 && m.focusedPanel == side {
-			style = ui.SelectedRowStyle
+	case findCopyMsg:
+		m.mode = modeBrowse
+		dest := otherPanel(msg.side)
+		if m.archiveView(dest) != nil {
+			return m, nil // Archives are read-only
 		}
-		content.WriteString(style.Render(fmt.Sprintf("%s [DIR] ..", cursorIcon)) + "\n")
-	}
+		return m, copyFindResultsCmd(m.sshClient, msg.side, msg.results, m.panelPath(dest), m.symlinkPolicy)
 
-	// Render files
-	for i, file := range files {
-		displayIndex := i
-		if (side == LeftPanel && !isLocalRoot(m.localPath)) || (side == RightPanel && m.remotePath != "/") {
-			displayIndex = i + 1
+	case archiveOpenedMsg:
+		if msg.err != nil {
+			return m, m.notify.Error(msg.err)
 		}
+		m.showArchive(msg)
+		return m, nil
 
-		cursorIcon := " "
-		if cursor == displayIndex {
-			cursorIcon = ">"
-			// DEBUG: Show which file has the cursor
-			if side == LeftPanel {
-				fmt.Printf("DEBUG CURSOR: Cursor on file[%d]: %s (displayIndex=%d, cursor=%d)\n", i, file.Name, displayIndex, cursor)
-			}
-		}
+	case commandDoneMsg:
+		msg.output.Update(msg)
+		// The command may have changed files
+		return m, m.loadPanelsCmd()
 
-		selectIcon := " "
-		if selected[i] {
-			selectIcon = "✓"
-			// DEBUG: Show which file is getting the checkmark
-			if side == LeftPanel {
-				fmt.Printf("DEBUG DISPLAY: Showing checkmark for file[%d]: %s (displayIndex=%d)\n", i, file.Name, displayIndex)
-			}
+	case previewLoadedMsg:
+		if msg.key == m.preview.key {
+			m.preview.setLoaded(msg)
+		} else if m.grep != nil {
+			return m.grep.Update(msg)
 		}
+		return m, nil
 
-		fileType := "FILE"
-		if file.IsDir {
-			fileType = "DIR"
+	case errMsg:
+		// A failed operation may have done part of its work, so show what is there now
+		return m, tea.Batch(m.notify.Error(msg.err), m.loadPanelsCmd())
+
+	case fileOpDoneMsg, copyCompleteMsg:
+		// The operation consumed the selections
+		m.localSelected.clear()
+		m.remoteSelected.clear()
+		m.selectRange = nil
+		return m, m.loadPanelsCmd()
+
+	case promptSubmittedMsg:
+		m.mode = modeBrowse
+		return m, m.prompt.onSubmit(msg.value)
+
+	case overwriteCheckMsg:
+		return m.confirmOverwrite(msg)
+
+	case confirmAcceptedMsg:
+		m.mode = modeBrowse
+		return m, m.confirm.onConfirm()
+
+	case permissionsLoadedMsg:
+		return m.openPermissionsDialog(msg)
+
+	case permissionsAppliedMsg:
+		m.mode = modeBrowse
+		return m, m.permissions.onApply(msg.change)
+
+	case editReadyMsg:
+		return m, runEditorCmd(msg.session)
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			return m, editorFailedCmd(msg.session, msg.err)
 		}
+		return m, finishEditCmd(m.sshClient, msg.session)
 
-		style := ui.RegularRowStyle
-		if i%2 == 0 {
-			style = ui.DimRowStyle
+	case editorFailedMsg:
+		return m.confirmFailedEdit(msg)
+
+	case editConflictMsg:
+		return m.confirmEditConflict(msg.session)
+
+	case dialogCancelledMsg:
+		m.mode = modeBrowse
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		m.resizePanels()
+		if m.tail != nil {
+			m.tail.setSize(m.width-2, m.height-4)
+		}
+		if m.output != nil {
+			m.output.setSize(m.width-2, m.height-4)
 		}
-		if cursor == displayIndex && m.focusedPanel == side {
-			style = ui.SelectedRowStyle
+		if m.find != nil {
+			m.find.setSize(m.width, m.height)
+		}
+		if m.grep != nil {
+			m.grep.setSize(m.width, m.height)
+		}
+		if m.mode == modePager {
+			m.preview.setSize(m.width-2, m.height-4)
 		}
 
-		line := fmt.Sprintf("%s %s [%s] %s (%d bytes)",
-			cursorIcon, selectIcon, fileType, file.Name, file.Size)
+	case tea.KeyMsg:
+		switch m.mode {
+		case modePrompt:
+			newModel, newCmd := m.prompt.Update(msg)
+			m.prompt = newModel.(*promptModel)
+			return m, newCmd
+		case modeConfirm:
+			newModel, newCmd := m.confirm.Update(msg)
+			m.confirm = newModel.(*confirmModel)
+			return m, newCmd
+		case modePermissions:
+			newModel, newCmd := m.permissions.Update(msg)
+			m.permissions = newModel.(*permissionsModel)
+			return m, newCmd
+		case modePager:
+			return m.updatePager(msg)
+		case modeTail:
+			newModel, newCmd := m.tail.Update(msg)
+			m.tail = newModel.(*tailModel)
+			return m, newCmd
+		case modeCommandOutput:
+			newModel, newCmd := m.output.Update(msg)
+			m.output = newModel.(*commandOutputModel)
+			return m, newCmd
+		case modeFind:
+			newModel, newCmd := m.find.Update(msg)
+			m.find = newModel.(*findModel)
+			return m, newCmd
+		case modeGrep:
+			newModel, newCmd := m.grep.Update(msg)
+			m.grep = newModel.(*grepModel)
+			return m, newCmd
+		case modeBookmarks:
+			newModel, newCmd := m.bookmarks.Update(msg)
+			m.bookmarks = newModel.(*bookmarksModel)
+			return m, newCmd
+		case modeBasket:
+			newModel, newCmd := m.basketView.Update(msg)
+			m.basketView = newModel.(*basketModel)
+			return m, newCmd
+		case modeErrorLog:
+			return m.updateErrorLog(msg)
+		case modeHelp:
+			return m.updateHelp(msg)
+		}
+		newModel, newCmd := m.handleKeyPress(msg)
+		// Follow the cursor with the preview
+		return newModel, tea.Batch(newCmd, m.refreshPreviewCmd())
+	}
 
-		content.WriteString(style.Render(line) + "\n")
+	// Keep the prompt's cursor blinking
+	if m.mode == modePrompt {
+		newModel, newCmd := m.prompt.Update(msg)
+		m.prompt = newModel.(*promptModel)
+		cmds = append(cmds, newCmd)
 	}
 
-	return content.String()
+	return m, tea.Batch(cmds...)
 }
 
 // headerView creates a header for a panel similar to the pager example
@@ -323,11 +394,10 @@ func (m *fileBrowserModel) headerView(side PanelSide) string {
 
 	if side == LeftPanel {
 		title = "Local Files"
-		panelWidth = m.leftViewport.Width
 	} else {
 		title = "Remote Files"
-		panelWidth = m.rightViewport.Width
 	}
+	panelWidth = m.view(side).width
 
 	titleStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("252")).
@@ -341,26 +411,177 @@ func (m *fileBrowserModel) headerView(side PanelSide) string {
 
 // footerView creates a footer showing scroll position similar to the pager example
 func (m *fileBrowserModel) footerView(side PanelSide) string {
-	var viewport viewport.Model
-	var panelWidth int
-
-	if side == LeftPanel {
-		viewport = m.leftViewport
-		panelWidth = m.leftViewport.Width
-	} else {
-		viewport = m.rightViewport
-		panelWidth = m.rightViewport.Width
-	}
+	view := m.view(side)
 
 	infoStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
 		Padding(0, 1)
 
-	info := infoStyle.Render(fmt.Sprintf("%3.f%%", viewport.ScrollPercent()*100))
-	line := strings.Repeat("─", max(0, panelWidth-lipgloss.Width(info)))
+	info := fmt.Sprintf("%s • %3.f%%", m.sortOrder(side), view.scrollPercent(m.cursor(side), m.listingRows(side))*100)
+	if summary := m.selectionSummary(side); summary != "" {
+		info = summary + " • " + info
+	}
+	info = infoStyle.Render(info)
+	line := strings.Repeat("─", max(0, view.width-lipgloss.Width(info)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
 }
 
+// otherPanel returns the panel opposite to side
+func otherPanel(side PanelSide) PanelSide {
+	if side == LeftPanel {
+		return RightPanel
+	}
+	return LeftPanel
+}
+
+// capturesInput reports whether key presses are going to a text input
+// and must not be treated as global shortcuts
+func (m *fileBrowserModel) capturesInput() bool {
+	return m.mode == modePrompt || m.mode == modePermissions || m.mode == modePager || m.mode == modeTail || m.mode == modeCommandOutput || m.mode == modeFind || m.mode == modeGrep || m.mode == modeBookmarks || m.mode == modeBasket || m.mode == modeErrorLog || m.mode == modeHelp
+}
+
+// hasParentEntry reports whether a panel shows a ".." entry above its files
+func (m *fileBrowserModel) hasParentEntry(side PanelSide) bool {
+	if m.archiveView(side) != nil {
+		// ".." leaves the archive from its root
+		return true
+	}
+	if side == LeftPanel {
+		return !isLocalRoot(m.localPath)
+	}
+	return m.remotePath != "/"
+}
+
+// placeCursor moves a panel's cursor onto the named file if it is listed
+func (m *fileBrowserModel) placeCursor(side PanelSide, name string) {
+	files := m.panelFiles(side)
+	for i := range files.len() {
+		if files.at(i).Name != name {
+			continue
+		}
+		if m.hasParentEntry(side) {
+			i++ // Account for ".." entry
+		}
+		if side == LeftPanel {
+			m.localCursor = i
+		} else {
+			m.remoteCursor = i
+		}
+		m.ensureCursorVisible(side)
+		return
+	}
+}
+
+// maxCursor returns the largest valid cursor position for a panel
+func (m *fileBrowserModel) maxCursor(side PanelSide) int {
+	files := m.panelFiles(side)
+	if m.hasParentEntry(side) {
+		return files.len() // Account for ".." entry
+	}
+	return max(0, files.len()-1)
+}
+
+// listedFiles returns all files of a panel's directory, before filtering
+func (m *fileBrowserModel) listedFiles(side PanelSide) []ssh.FileInfo {
+	if view := m.archiveView(side); view != nil {
+		return view.files
+	}
+	if side == LeftPanel {
+		return m.localFiles
+	}
+	return m.remoteFiles
+}
+
+// panelFiles returns the files listed in a panel, narrowed by its filter
+func (m *fileBrowserModel) panelFiles(side PanelSide) *panelListing {
+	listing := &m.localListing
+	if side == RightPanel {
+		listing = &m.remoteListing
+	}
+	listing.update(m.listedFiles(side), *m.filter(side))
+	return listing
+}
+
+// invalidateListing makes a panel's listing be rebuilt after its files were
+// changed in place, such as by sorting
+func (m *fileBrowserModel) invalidateListing(side PanelSide) {
+	if side == LeftPanel {
+		m.localListing = panelListing{}
+	} else {
+		m.remoteListing = panelListing{}
+	}
+}
+
+// panelPath returns the directory shown in a panel
+func (m *fileBrowserModel) panelPath(side PanelSide) string {
+	if side == LeftPanel {
+		return m.localPath
+	}
+	return m.remotePath
+}
+
+// cursorFileIndex returns the index of the file under the cursor, or -1 when
+// the cursor is on the ".." entry or the panel is empty
+func (m *fileBrowserModel) cursorFileIndex(side PanelSide) int {
+	fileIndex := m.localCursor
+	if side == RightPanel {
+		fileIndex = m.remoteCursor
+	}
+	if m.hasParentEntry(side) {
+		fileIndex-- // Account for ".." entry
+	}
+	if fileIndex < 0 || fileIndex >= m.panelFiles(side).len() {
+		return -1
+	}
+	return fileIndex
+}
+
+// cursorFile returns the file under the cursor of a panel
+func (m *fileBrowserModel) cursorFile(side PanelSide) (ssh.FileInfo, bool) {
+	fileIndex := m.cursorFileIndex(side)
+	if fileIndex < 0 {
+		return ssh.FileInfo{}, false
+	}
+	return m.panelFiles(side).at(fileIndex), true
+}
+
+// selection returns the selected files of a panel
+func (m *fileBrowserModel) selection(side PanelSide) *fileSelection {
+	if side == LeftPanel {
+		return m.localSelected
+	}
+	return m.remoteSelected
+}
+
+// selectedFiles returns the selected files of a panel in listing order,
+// including any that its filter currently hides
+func (m *fileBrowserModel) selectedFiles(side PanelSide) []ssh.FileInfo {
+	selected := m.selection(side)
+	if selected.len() == 0 {
+		return nil
+	}
+
+	var files []ssh.FileInfo
+	for _, file := range m.listedFiles(side) {
+		if selected.has(file.Name) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// targetFiles returns the selected files of the focused panel, falling back
+// to the file under the cursor when nothing is selected
+func (m *fileBrowserModel) targetFiles() []ssh.FileInfo {
+	targets := m.selectedFiles(m.focusedPanel)
+	if len(targets) == 0 {
+		if file, ok := m.cursorFile(m.focusedPanel); ok {
+			targets = append(targets, file)
+		}
+	}
+	return targets
+}
+
 // max returns the maximum of two integers
 func max(a, b int) int {
 	if a > b {
@@ -396,6 +617,18 @@ func isLocalRoot(localPath string) bool {
 
 // handleKeyPress handles keyboard input
 func (m *fileBrowserModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Archives are read-only and can't be the target of file operations
+	if m.archiveView(m.focusedPanel) != nil && !archiveKeys[msg.String()] {
+		return m, nil
+	}
+	if m.archiveView(otherPanel(m.focusedPanel)) != nil && otherPanelKeys[msg.String()] {
+		return m, nil
+	}
+	if !rangeKeys[msg.String()] {
+		// Any other key ends a range selection
+		m.selectRange = nil
+	}
+
 	switch msg.String() {
 	case "tab":
 		// Switch focused panel
@@ -404,46 +637,24 @@ func (m *fileBrowserModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		} else {
 			m.focusedPanel = LeftPanel
 		}
-		// Update content to reflect focus change
-		m.updateViewportContent()
 
 	case "up", "k":
-		if m.focusedPanel == LeftPanel {
-			if m.localCursor > 0 {
-				m.localCursor--
-				m.updateViewportContent()
-				m.ensureCursorVisible(LeftPanel)
-			}
-		} else {
-			if m.remoteCursor > 0 {
-				m.remoteCursor--
-				m.updateViewportContent()
-				m.ensureCursorVisible(RightPanel)
-			}
-		}
+		m.moveCursor(m.focusedPanel, -1)
 
 	case "down", "j":
-		if m.focusedPanel == LeftPanel {
-			maxCursor := len(m.localFiles) - 1
-			if !isLocalRoot(m.localPath) {
-				maxCursor = len(m.localFiles) // Account for ".." entry
-			}
-			if m.localCursor < maxCursor {
-				m.localCursor++
-				m.updateViewportContent()
-				m.ensureCursorVisible(LeftPanel)
-			}
-		} else {
-			maxCursor := len(m.remoteFiles) - 1
-			if m.remotePath != "/" {
-				maxCursor = len(m.remoteFiles) // Account for ".." entry
-			}
-			if m.remoteCursor < maxCursor {
-				m.remoteCursor++
-				m.updateViewportContent()
-				m.ensureCursorVisible(RightPanel)
-			}
-		}
+		m.moveCursor(m.focusedPanel, 1)
+
+	case "pgup":
+		m.moveCursor(m.focusedPanel, -m.view(m.focusedPanel).rows())
+
+	case "pgdown":
+		m.moveCursor(m.focusedPanel, m.view(m.focusedPanel).rows())
+
+	case "home":
+		m.moveCursor(m.focusedPanel, -m.maxCursor(m.focusedPanel))
+
+	case "end":
+		m.moveCursor(m.focusedPanel, m.maxCursor(m.focusedPanel))
 
 	case "enter":
 		return m.handleEnterDirectory()
@@ -455,161 +666,228 @@ func (m *fileBrowserModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleEnterDirectoryOnly()
 
 	case " ":
-		// Toggle selection
-		if m.focusedPanel == LeftPanel {
-			// Calculate the actual file index, accounting for ".." entry
-			fileIndex := m.localCursor
-			if !isLocalRoot(m.localPath) {
-				fileIndex = m.localCursor - 1 // Subtract 1 to account for ".." entry
-			}
-			// DEBUG: Print debug info
-			fmt.Printf("DEBUG: cursor=%d, isRoot=%v, fileIndex=%d, numFiles=%d\n",
-				m.localCursor, isLocalRoot(m.localPath), fileIndex, len(m.localFiles))
-			if fileIndex >= 0 && fileIndex < len(m.localFiles) {
-				fmt.Printf("DEBUG: Toggling selection for file[%d]: %s\n", fileIndex, m.localFiles[fileIndex].Name)
-			}
-			// Only toggle selection for actual files, not ".." entry
-			if fileIndex >= 0 && fileIndex < len(m.localFiles) {
-				m.localSelected[fileIndex] = !m.localSelected[fileIndex]
-			}
-		} else {
-			// Calculate the actual file index, accounting for ".." entry
-			fileIndex := m.remoteCursor
-			if m.remotePath != "/" {
-				fileIndex = m.remoteCursor - 1 // Subtract 1 to account for ".." entry
-			}
-			// Only toggle selection for actual files, not ".." entry
-			if fileIndex >= 0 && fileIndex < len(m.remoteFiles) {
-				m.remoteSelected[fileIndex] = !m.remoteSelected[fileIndex]
-			}
+		// Toggle selection, which doesn't apply to the ".." entry
+		if file, ok := m.cursorFile(m.focusedPanel); ok {
+			m.selection(m.focusedPanel).toggle(file)
 		}
-		// Update content to reflect selection change
-		m.updateViewportContent()
+
+	case "shift+up", "K":
+		return m.handleRangeSelect(-1)
+
+	case "shift+down", "J":
+		return m.handleRangeSelect(1)
+
+	case "a":
+		return m.handleSelectAll()
+
+	case "*":
+		return m.handleInvertSelection()
+
+	case "+":
+		return m.handleGlobSelect()
+
+	case "y":
+		return m.handleAddToBasket()
+
+	case "Y":
+		return m.handleBasket()
+
+	case "-":
+		return m.handleClearSelection()
 
 	case "c":
 		// Copy selected files
 		return m.handleCopy()
-	}
 
-	return m, nil
-}
+	case "d", "delete":
+		return m.handleDelete()
 
-// handleEnterDirectory handles entering a directory
-func (m *fileBrowserModel) handleEnterDirectory() (tea.Model, tea.Cmd) {
-	if m.focusedPanel == LeftPanel {
-		// Check if we're selecting ".." (go up directory)
-		if m.localCursor == 0 && !isLocalRoot(m.localPath) {
-			m.localPath = filepath.Dir(m.localPath)
-			m.localCursor = 0
-			return m, loadFilesCmd(m)
-		}
+	case "R":
+		return m.handleRename()
 
-		// Calculate the actual file index, accounting for ".." entry
-		fileIndex := m.localCursor
-		if !isLocalRoot(m.localPath) {
-			fileIndex = m.localCursor - 1 // Subtract 1 to account for ".." entry
-		}
+	case "n":
+		return m.handleMkdir()
 
-		if fileIndex >= 0 && fileIndex < len(m.localFiles) && m.localFiles[fileIndex].IsDir {
-			m.localPath = filepath.Join(m.localPath, m.localFiles[fileIndex].Name)
-			m.localCursor = 0
-			return m, loadFilesCmd(m)
-		}
-	} else {
-		// Check if we're selecting ".." (go up directory)
-		if m.remoteCursor == 0 && m.remotePath != "/" {
-			m.remotePath = remotePathDir(m.remotePath)
-			m.remoteCursor = 0
-			return m, loadFilesCmd(m)
-		}
+	case "m":
+		return m.handleMove()
 
-		// Calculate the actual file index, accounting for ".." entry
-		fileIndex := m.remoteCursor
-		if m.remotePath != "/" {
-			fileIndex = m.remoteCursor - 1 // Subtract 1 to account for ".." entry
-		}
+	case "M":
+		return m.handleMoveWithinSide()
 
-		if fileIndex >= 0 && fileIndex < len(m.remoteFiles) && m.remoteFiles[fileIndex].IsDir {
-			m.remotePath = remotePathJoin(m.remotePath, m.remoteFiles[fileIndex].Name)
-			m.remoteCursor = 0
-			return m, loadFilesCmd(m)
-		}
+	case "p":
+		return m.handlePermissions()
+
+	case "C":
+		return m.handleColumns()
+
+	case "e":
+		return m.handleEdit()
+
+	case "v":
+		return m.handleTogglePreview()
+
+	case "V":
+		return m.handleOpenPager()
+
+	case "t":
+		return m.handleTail()
+
+	case "S":
+		return m.handleShell()
+
+	case "!":
+		return m.handleRunCommand()
+
+	case "z":
+		return m.handleArchiveDownload(false)
+
+	case "Z":
+		return m.handleArchiveDownload(true)
+
+	case "u":
+		return m.handleArchiveUpload()
+
+	case "x":
+		return m.handleExtractHere()
+
+	case "g":
+		return m.handleGoTo()
+
+	case "b":
+		return m.handleBookmarks()
+
+	case "B":
+		return m.handleAddBookmark(false)
+
+	case "f":
+		return m.handleFind()
+
+	case "F":
+		return m.handleGrep()
+
+	case "s":
+		return m.handleSort(func(order *ssh.SortOrder) { order.Key = order.Key.Next() })
+
+	case "o":
+		return m.handleSort(func(order *ssh.SortOrder) { order.Descending = !order.Descending })
+
+	case "D":
+		return m.handleSort(func(order *ssh.SortOrder) { order.DirsFirst = !order.DirsFirst })
+
+	case "/":
+		return m.handleFilter()
+
+	case ".":
+		return m.handleToggleDotfiles()
+
+	case "esc":
+		return m.handleClearFilter()
+
+	case "[", "alt+left":
+		return m.handleHistory(true)
+
+	case "]", "alt+right":
+		return m.handleHistory(false)
+
+	case "r":
+		return m, m.loadPanelCmd(m.focusedPanel)
+
+	case "E":
+		m.mode = modeErrorLog
+
+	case "?":
+		m.mode = modeHelp
+
+	case "L":
+		// Cycle how symlinks are treated by copy and move
+		m.symlinkPolicy = m.symlinkPolicy.Next()
 	}
+
 	return m, nil
 }
 
+// handleEnterDirectory handles entering a directory
+func (m *fileBrowserModel) handleEnterDirectory() (tea.Model, tea.Cmd) {
+	if m.archiveView(m.focusedPanel) != nil {
+		return m.handleArchiveEnter()
+	}
+	if file, ok := m.cursorFile(m.focusedPanel); ok && !file.IsDir && ssh.IsBrowsableArchive(file.Name) {
+		return m.handleOpenArchive(file)
+	}
+
+	return m, m.enterCursorDir(m.focusedPanel)
+}
+
 // handleEnterDirectoryOnly handles entering a directory with right arrow (only works on directories)
 func (m *fileBrowserModel) handleEnterDirectoryOnly() (tea.Model, tea.Cmd) {
-	if m.focusedPanel == LeftPanel {
-		// Check if we're selecting ".." (go up directory)
-		if m.localCursor == 0 && !isLocalRoot(m.localPath) {
-			m.localPath = filepath.Dir(m.localPath)
-			m.localCursor = 0
-			return m, loadFilesCmd(m)
-		}
-
-		// Calculate the actual file index, accounting for ".." entry
-		fileIndex := m.localCursor
-		if !isLocalRoot(m.localPath) {
-			fileIndex = m.localCursor - 1 // Subtract 1 to account for ".." entry
-		}
+	if m.archiveView(m.focusedPanel) != nil {
+		return m.handleArchiveEnter()
+	}
 
-		// Only enter if it's a directory (not a regular file)
-		if fileIndex >= 0 && fileIndex < len(m.localFiles) && m.localFiles[fileIndex].IsDir {
-			m.localPath = filepath.Join(m.localPath, m.localFiles[fileIndex].Name)
-			m.localCursor = 0
-			return m, loadFilesCmd(m)
-		}
-	} else {
-		// Check if we're selecting ".." (go up directory)
-		if m.remoteCursor == 0 && m.remotePath != "/" {
-			m.remotePath = remotePathDir(m.remotePath)
-			m.remoteCursor = 0
-			return m, loadFilesCmd(m)
-		}
+	return m, m.enterCursorDir(m.focusedPanel)
+}
 
-		// Calculate the actual file index, accounting for ".." entry
-		fileIndex := m.remoteCursor
-		if m.remotePath != "/" {
-			fileIndex = m.remoteCursor - 1 // Subtract 1 to account for ".." entry
-		}
+// enterCursorDir enters the directory under the cursor of a panel, or goes up
+// when the cursor is on ".."
+func (m *fileBrowserModel) enterCursorDir(side PanelSide) tea.Cmd {
+	cursor := m.localCursor
+	if side == RightPanel {
+		cursor = m.remoteCursor
+	}
+	if cursor == 0 && m.hasParentEntry(side) {
+		return m.goUp(side)
+	}
 
-		// Only enter if it's a directory (not a regular file)
-		if fileIndex >= 0 && fileIndex < len(m.remoteFiles) && m.remoteFiles[fileIndex].IsDir {
-			m.remotePath = remotePathJoin(m.remotePath, m.remoteFiles[fileIndex].Name)
-			m.remoteCursor = 0
-			return m, loadFilesCmd(m)
-		}
+	file, ok := m.cursorFile(side)
+	if !ok || !file.IsDir {
+		return nil
 	}
-	return m, nil
+	if side == LeftPanel {
+		return m.changeDir(side, filepath.Join(m.localPath, file.Name), "")
+	}
+	return m.changeDir(side, remotePathJoin(m.remotePath, file.Name), "")
 }
 
 // handleGoUpDirectory handles going up one directory level
 func (m *fileBrowserModel) handleGoUpDirectory() (tea.Model, tea.Cmd) {
-	if m.focusedPanel == LeftPanel {
-		m.localPath = filepath.Dir(m.localPath)
-		m.localCursor = 0
-	} else {
-		m.remotePath = remotePathDir(m.remotePath)
-		m.remoteCursor = 0
+	if m.archiveView(m.focusedPanel) != nil {
+		return m.handleArchiveUp()
 	}
-	return m, loadFilesCmd(m)
+
+	return m, m.goUp(m.focusedPanel)
 }
 
 // View renders the file browser
 func (m *fileBrowserModel) View() string {
-	if m.err != nil {
-		return ui.ErrorStyle.Render(fmt.Sprintf("Error: %s", m.err.Error()))
-	}
-
 	if !m.ready {
 		return "\n  Initializing file browser..."
 	}
 
 	panelWidth := (m.width - 4) / 2 // Account for borders and spacing
 
-	leftPanel := m.renderViewportPanel(LeftPanel, panelWidth)
-	rightPanel := m.renderViewportPanel(RightPanel, panelWidth)
+	switch m.mode {
+	case modePager:
+		return m.pagerView()
+	case modeTail:
+		return m.tail.View()
+	case modeCommandOutput:
+		return m.output.View()
+	case modeFind:
+		return m.find.View()
+	case modeGrep:
+		return m.grep.View()
+	}
+
+	leftPanel := m.renderPanel(LeftPanel, panelWidth)
+	rightPanel := m.renderPanel(RightPanel, panelWidth)
+	if m.showPreview {
+		// The preview replaces the panel that doesn't have focus
+		if m.focusedPanel == LeftPanel {
+			rightPanel = m.renderPreviewPanel(panelWidth)
+		} else {
+			leftPanel = m.renderPreviewPanel(panelWidth)
+		}
+	}
 
 	panels := lipgloss.JoinHorizontal(
 		lipgloss.Top,
@@ -617,25 +895,45 @@ func (m *fileBrowserModel) View() string {
 		rightPanel,
 	)
 
-	help := ui.HelpStyle.Render("tab: switch panel • ↑/↓/PgUp/PgDn: navigate • ←/→: go up/into dir • space: select • c: copy • q: quit")
-
-	return lipgloss.JoinVertical(lipgloss.Left, panels, help)
-}
+	switch m.mode {
+	case modeConfirm:
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.confirm.View())
+	case modePermissions:
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.permissions.View())
+	case modeBookmarks:
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.bookmarks.View())
+	case modeBasket:
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.basketView.View())
+	case modeErrorLog:
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.notify.LogView(m.width, m.height))
+	case modeHelp:
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.helpView(m.width))
+	}
 
-// renderViewportPanel renders a single panel using viewport with header and footer
-func (m *fileBrowserModel) renderViewportPanel(side PanelSide, width int) string {
-	var viewport viewport.Model
+	if m.mode == modePrompt {
+		return lipgloss.JoinVertical(lipgloss.Left, panels, m.prompt.View())
+	}
 
-	if side == LeftPanel {
-		viewport = m.leftViewport
-	} else {
-		viewport = m.rightViewport
+	// The help line stays on one line so the panels keep their headers
+	help := ui.HelpStyle.MaxWidth(m.width).Render(shortHelp)
+	if m.archive != nil {
+		// Show transfer progress in place of the help while it runs
+		help = "\n" + m.archive.View()
+	}
+	if toasts := m.notify.ToastView(m.width); toasts != "" {
+		// Notifications take the place of the help until they are dismissed
+		help = "\n" + toasts
 	}
 
+	return lipgloss.JoinVertical(lipgloss.Left, panels, help)
+}
+
+// renderPanel renders a single panel with header and footer
+func (m *fileBrowserModel) renderPanel(side PanelSide, width int) string {
 	header := m.headerView(side)
 	footer := m.footerView(side)
 
-	content := fmt.Sprintf("%s\n%s\n%s", header, viewport.View(), footer)
+	content := fmt.Sprintf("%s\n%s\n%s", header, m.renderPanelBody(side), footer)
 
 	// Apply panel style based on focus
 	panelStyle := ui.UnfocusedPanelStyle
@@ -648,6 +946,10 @@ func (m *fileBrowserModel) renderViewportPanel(side PanelSide, width int) string
 
 // handleCopy handles copying selected files
 func (m *fileBrowserModel) handleCopy() (tea.Model, tea.Cmd) {
+	if m.archiveView(m.focusedPanel) != nil {
+		return m.handleCopyFromArchive()
+	}
+
 	var selectedFiles []string
 	var sourcePath, destPath string
 	var isLocalToRemote bool
@@ -657,20 +959,16 @@ func (m *fileBrowserModel) handleCopy() (tea.Model, tea.Cmd) {
 		isLocalToRemote = true
 		sourcePath = m.localPath
 		destPath = m.remotePath
-		for i, selected := range m.localSelected {
-			if selected && i < len(m.localFiles) {
-				selectedFiles = append(selectedFiles, m.localFiles[i].Name)
-			}
+		for _, file := range m.selectedFiles(LeftPanel) {
+			selectedFiles = append(selectedFiles, file.Name)
 		}
 	} else {
 		// Copy from remote to local
 		isLocalToRemote = false
 		sourcePath = m.remotePath
 		destPath = m.localPath
-		for i, selected := range m.remoteSelected {
-			if selected && i < len(m.remoteFiles) {
-				selectedFiles = append(selectedFiles, m.remoteFiles[i].Name)
-			}
+		for _, file := range m.selectedFiles(RightPanel) {
+			selectedFiles = append(selectedFiles, file.Name)
 		}
 	}
 
@@ -679,23 +977,25 @@ func (m *fileBrowserModel) handleCopy() (tea.Model, tea.Cmd) {
 	}
 
 	// Start copy operation
-	return m, copyFilesCmd(m.sshClient, selectedFiles, sourcePath, destPath, isLocalToRemote)
+	return m, copyFilesCmd(m.sshClient, selectedFiles, sourcePath, destPath, isLocalToRemote, m.symlinkPolicy)
 }
 
 // copyFilesCmd creates a command to copy files
-func copyFilesCmd(client *ssh.Client, files []string, sourcePath, destPath string, isLocalToRemote bool) tea.Cmd {
+func copyFilesCmd(client *ssh.Client, files []string, sourcePath, destPath string, isLocalToRemote bool, policy ssh.SymlinkPolicy) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		for i, file := range files {
 			var err error
 			if isLocalToRemote {
-				err = client.CopyFileFromLocal(
+				err = client.UploadPath(
 					filepath.Join(sourcePath, file),
 					remotePathJoin(destPath, file),
+					policy,
 				)
 			} else {
-				err = client.CopyFileToLocal(
+				err = client.DownloadPath(
 					remotePathJoin(sourcePath, file),
 					filepath.Join(destPath, file),
+					policy,
 				)
 			}
 
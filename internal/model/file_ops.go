@@ -0,0 +1,369 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fileOpDoneMsg is sent when a file operation has finished and the panels need reloading
+type fileOpDoneMsg struct{}
+
+// handleDelete asks for confirmation before deleting the targeted files
+func (m *fileBrowserModel) handleDelete() (tea.Model, tea.Cmd) {
+	files := m.targetFiles()
+	if len(files) == 0 {
+		return m, nil
+	}
+
+	side := m.focusedPanel
+	dir := m.panelPath(side)
+
+	items := make([]string, len(files))
+	for i, file := range files {
+		if file.IsLink {
+			items[i] = fmt.Sprintf("[LINK] %s -> %s", file.Name, file.LinkTarget)
+		} else if file.IsDir {
+			items[i] = fmt.Sprintf("[DIR] %s", file.Name)
+		} else {
+			items[i] = fmt.Sprintf("[FILE] %s", file.Name)
+		}
+	}
+
+	title := fmt.Sprintf("Delete %d item(s) from %s?", len(files), dir)
+	m.confirm = newConfirmModel(title, items, func() tea.Cmd {
+		return deleteFilesCmd(m.sshClient, side, dir, files)
+	})
+	m.mode = modeConfirm
+	return m, m.confirm.Init()
+}
+
+// handleRename prompts for a new name for the file under the cursor
+func (m *fileBrowserModel) handleRename() (tea.Model, tea.Cmd) {
+	file, ok := m.cursorFile(m.focusedPanel)
+	if !ok {
+		return m, nil
+	}
+
+	side := m.focusedPanel
+	dir := m.panelPath(side)
+
+	m.prompt = newPromptModel(fmt.Sprintf("Rename %s to:", file.Name), file.Name, func(value string) tea.Cmd {
+		name := strings.TrimSpace(value)
+		if name == "" || name == file.Name {
+			return nil
+		}
+		return renameFileCmd(m.sshClient, side, dir, file.Name, name)
+	})
+	m.prompt.validate = func(value string) error {
+		if name := strings.TrimSpace(value); name != "" {
+			return validateFileName(name)
+		}
+		return nil
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// handleMkdir prompts for the name of a new directory in the focused panel
+func (m *fileBrowserModel) handleMkdir() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	dir := m.panelPath(side)
+
+	m.prompt = newPromptModel(fmt.Sprintf("New directory in %s:", dir), "", func(value string) tea.Cmd {
+		name := strings.TrimSpace(value)
+		if name == "" {
+			return nil
+		}
+		return mkdirCmd(m.sshClient, side, dir, name)
+	})
+	m.prompt.validate = func(value string) error {
+		if name := strings.TrimSpace(value); name != "" {
+			return validateDirPath(name)
+		}
+		return nil
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// validateFileName rejects names that would escape the current directory
+func validateFileName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid file name: %q", name)
+	}
+	return nil
+}
+
+// validateDirPath rejects new directory paths that would escape the current
+// directory. Unlike file names they may be nested, e.g. "a/b/c".
+func validateDirPath(name string) error {
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || validateFileName(part) != nil {
+			return fmt.Errorf("invalid directory path: %q", name)
+		}
+	}
+	return nil
+}
+
+// deleteFilesCmd creates a command to delete files on one side
+func deleteFilesCmd(client *ssh.Client, side PanelSide, dir string, files []ssh.FileInfo) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		for _, file := range files {
+			var err error
+			if side == LeftPanel {
+				target := filepath.Join(dir, file.Name)
+				if file.IsDir {
+					err = ssh.RemoveAllLocal(target)
+				} else {
+					err = ssh.RemoveLocal(target)
+				}
+			} else {
+				target := remotePathJoin(dir, file.Name)
+				if file.IsDir {
+					err = client.RemoveAll(target)
+				} else {
+					err = client.Remove(target)
+				}
+			}
+
+			if err != nil {
+				return errMsg{fmt.Errorf("failed to delete %s: %w", file.Name, err)}
+			}
+		}
+
+		return fileOpDoneMsg{}
+	})
+}
+
+// renameFileCmd creates a command to rename a file within a directory
+func renameFileCmd(client *ssh.Client, side PanelSide, dir, oldName, newName string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		var err error
+		if side == LeftPanel {
+			err = ssh.RenameLocal(filepath.Join(dir, oldName), filepath.Join(dir, newName))
+		} else {
+			err = client.Rename(remotePathJoin(dir, oldName), remotePathJoin(dir, newName))
+		}
+
+		if err != nil {
+			return errMsg{err}
+		}
+		return fileOpDoneMsg{}
+	})
+}
+
+// mkdirCmd creates a command to make a directory, creating parents when the name is a nested path
+func mkdirCmd(client *ssh.Client, side PanelSide, dir, name string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		nested := strings.Contains(name, "/")
+
+		var err error
+		if side == LeftPanel {
+			target := filepath.Join(dir, filepath.FromSlash(name))
+			if nested {
+				err = ssh.MkdirAllLocal(target)
+			} else {
+				err = ssh.MkdirLocal(target)
+			}
+		} else {
+			target := remotePathJoin(dir, name)
+			if nested {
+				err = client.MkdirAll(target)
+			} else {
+				err = client.Mkdir(target)
+			}
+		}
+
+		if err != nil {
+			return errMsg{err}
+		}
+		return fileOpDoneMsg{}
+	})
+}
+
+// handleMove moves the targeted files into the other panel's directory
+func (m *fileBrowserModel) handleMove() (tea.Model, tea.Cmd) {
+	files := m.targetFiles()
+	if len(files) == 0 {
+		return m, nil
+	}
+
+	side := m.focusedPanel
+	sourcePath := m.panelPath(side)
+	destPath := m.panelPath(otherPanel(side))
+
+	// Skipping links would delete them with their source directory, so a move
+	// always carries links over as links unless they are to be followed
+	policy := m.symlinkPolicy
+	if policy == ssh.SymlinkSkip {
+		policy = ssh.SymlinkPreserve
+	}
+
+	// The source is deleted after the copy, so replacing anything at the
+	// destination needs confirming first
+	dests := make([]string, len(files))
+	for i, file := range files {
+		if side == LeftPanel {
+			dests[i] = remotePathJoin(destPath, file.Name)
+		} else {
+			dests[i] = filepath.Join(destPath, file.Name)
+		}
+	}
+	move := moveFilesCmd(m.sshClient, files, sourcePath, destPath, side == LeftPanel, policy)
+	return m, checkOverwriteCmd(m.sshClient, otherPanel(side), dests, "Move", move)
+}
+
+// handleMoveWithinSide prompts for a directory on the same side and renames the targeted files into it
+func (m *fileBrowserModel) handleMoveWithinSide() (tea.Model, tea.Cmd) {
+	files := m.targetFiles()
+	if len(files) == 0 {
+		return m, nil
+	}
+
+	side := m.focusedPanel
+	dir := m.panelPath(side)
+
+	title := fmt.Sprintf("Move %d item(s) to directory:", len(files))
+	m.prompt = newPromptModel(title, dir, func(value string) tea.Cmd {
+		dest := strings.TrimSpace(value)
+		if dest == "" || dest == dir {
+			return nil
+		}
+		return moveWithinSideCmd(m.sshClient, side, files, dir, dest)
+	})
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// overwriteCheckMsg reports which destinations of a transfer already exist
+type overwriteCheckMsg struct {
+	action   string   // What the transfer does, for the confirmation title
+	existing []string // Destinations the transfer would replace
+	start    tea.Cmd  // Starts the transfer
+}
+
+// checkOverwriteCmd creates a command that looks for existing files at the
+// destinations of a transfer into side, to confirm replacing them before start runs
+func checkOverwriteCmd(client *ssh.Client, side PanelSide, dests []string, action string, start tea.Cmd) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		existing, err := existingPaths(client, side, dests)
+		if err != nil {
+			return errMsg{err}
+		}
+		return overwriteCheckMsg{action: action, existing: existing, start: start}
+	})
+}
+
+// existingPaths returns the paths on one side that exist
+func existingPaths(client *ssh.Client, side PanelSide, paths []string) ([]string, error) {
+	var existing []string
+	for _, p := range paths {
+		var exists bool
+		var err error
+		if side == LeftPanel {
+			exists, err = ssh.LocalExists(p)
+		} else {
+			exists, err = client.Exists(p)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			existing = append(existing, p)
+		}
+	}
+	return existing, nil
+}
+
+// confirmOverwrite starts a checked transfer, asking first if it would replace existing files
+func (m *fileBrowserModel) confirmOverwrite(msg overwriteCheckMsg) (tea.Model, tea.Cmd) {
+	if len(msg.existing) == 0 {
+		return m, msg.start
+	}
+
+	title := fmt.Sprintf("%s would overwrite %d existing item(s). Continue?", msg.action, len(msg.existing))
+	m.confirm = newConfirmModel(title, msg.existing, func() tea.Cmd {
+		return msg.start
+	})
+	m.mode = modeConfirm
+	return m, m.confirm.Init()
+}
+
+// moveFilesCmd creates a command to move files between local and remote.
+// Every item is copied and verified before any source is deleted, so a failed
+// transfer never loses data.
+func moveFilesCmd(client *ssh.Client, files []ssh.FileInfo, sourcePath, destPath string, isLocalToRemote bool, policy ssh.SymlinkPolicy) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		for _, file := range files {
+			var err error
+			if isLocalToRemote {
+				err = client.UploadPath(filepath.Join(sourcePath, file.Name), remotePathJoin(destPath, file.Name), policy)
+			} else {
+				err = client.DownloadPath(remotePathJoin(sourcePath, file.Name), filepath.Join(destPath, file.Name), policy)
+			}
+			if err != nil {
+				return errMsg{fmt.Errorf("failed to move %s, nothing was deleted: %w", file.Name, err)}
+			}
+		}
+
+		for _, file := range files {
+			var err error
+			if isLocalToRemote {
+				err = client.VerifyUpload(filepath.Join(sourcePath, file.Name), remotePathJoin(destPath, file.Name), policy)
+			} else {
+				err = client.VerifyDownload(remotePathJoin(sourcePath, file.Name), filepath.Join(destPath, file.Name), policy)
+			}
+			if err != nil {
+				return errMsg{fmt.Errorf("failed to move %s, nothing was deleted: %w", file.Name, err)}
+			}
+		}
+
+		for _, file := range files {
+			var err error
+			if isLocalToRemote {
+				err = ssh.RemoveAllLocal(filepath.Join(sourcePath, file.Name))
+			} else {
+				err = client.RemoveAll(remotePathJoin(sourcePath, file.Name))
+			}
+			if err != nil {
+				return errMsg{fmt.Errorf("copied %s but failed to remove the source: %w", file.Name, err)}
+			}
+		}
+
+		return fileOpDoneMsg{}
+	})
+}
+
+// moveWithinSideCmd creates a command to rename files into another directory on the same side
+func moveWithinSideCmd(client *ssh.Client, side PanelSide, files []ssh.FileInfo, dir, dest string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		for _, file := range files {
+			var err error
+			if side == LeftPanel {
+				target := dest
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(dir, target)
+				}
+				err = ssh.RenameLocal(filepath.Join(dir, file.Name), filepath.Join(target, file.Name))
+			} else {
+				target := dest
+				if !strings.HasPrefix(target, "/") {
+					target = remotePathJoin(dir, target)
+				}
+				err = client.Rename(remotePathJoin(dir, file.Name), remotePathJoin(target, file.Name))
+			}
+
+			if err != nil {
+				return errMsg{err}
+			}
+		}
+
+		return fileOpDoneMsg{}
+	})
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestValidateFileName(t *testing.T) {
+	for _, name := range []string{"a.txt", ".hidden", "..."} {
+		if err := validateFileName(name); err != nil {
+			t.Errorf("validateFileName(%q) = %v, expected no error", name, err)
+		}
+	}
+	for _, name := range []string{".", "..", "a/b", `a\b`} {
+		if err := validateFileName(name); err == nil {
+			t.Errorf("validateFileName(%q) succeeded, expected an error", name)
+		}
+	}
+}
+
+func TestValidateDirPath(t *testing.T) {
+	for _, name := range []string{"logs", "a/b/c", ".cache"} {
+		if err := validateDirPath(name); err != nil {
+			t.Errorf("validateDirPath(%q) = %v, expected no error", name, err)
+		}
+	}
+	for _, name := range []string{".", "..", "a/..", "../a", "/abs", "a//b", "a/", `a\b`} {
+		if err := validateDirPath(name); err == nil {
+			t.Errorf("validateDirPath(%q) succeeded, expected an error", name)
+		}
+	}
+}
+
+func TestCheckOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "taken"), nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	started := func() tea.Msg { return fileOpDoneMsg{} }
+
+	m := newTestBrowser(0)
+	dests := []string{filepath.Join(dir, "free"), filepath.Join(dir, "taken")}
+	msg, ok := checkOverwriteCmd(nil, LeftPanel, dests, "Move", started)().(overwriteCheckMsg)
+	if !ok || len(msg.existing) != 1 || msg.existing[0] != dests[1] {
+		t.Fatalf("Expected only %s to be reported as existing, got %+v", dests[1], msg)
+	}
+	if _, cmd := m.confirmOverwrite(msg); cmd != nil || m.mode != modeConfirm {
+		t.Error("Expected replacing an existing file to be confirmed first")
+	}
+
+	m = newTestBrowser(0)
+	msg = checkOverwriteCmd(nil, LeftPanel, dests[:1], "Move", started)().(overwriteCheckMsg)
+	if _, cmd := m.confirmOverwrite(msg); cmd == nil || m.mode != modeBrowse {
+		t.Error("Expected a transfer replacing nothing to start right away")
+	}
+}
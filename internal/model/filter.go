@@ -0,0 +1,163 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// panelFilter narrows the files a panel lists
+type panelFilter struct {
+	text         string // Fuzzy matched against file names
+	hideDotfiles bool
+}
+
+// active reports whether the filter hides anything
+func (f panelFilter) active() bool {
+	return f.text != "" || f.hideDotfiles
+}
+
+// matches reports whether a file is listed with the filter applied
+func (f panelFilter) matches(file ssh.FileInfo) bool {
+	if f.hideDotfiles && ssh.IsHidden(file) {
+		return false
+	}
+	return ssh.FuzzyMatch(file.Name, f.text)
+}
+
+// label describes the filter for the panel header, "" when it is inactive
+func (f panelFilter) label() string {
+	var parts []string
+	if f.text != "" {
+		parts = append(parts, fmt.Sprintf("filter: %s", f.text))
+	}
+	if f.hideDotfiles {
+		parts = append(parts, "dotfiles hidden")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(parts, ", "))
+}
+
+// panelListing is the files a panel lists: its directory's files narrowed by
+// its filter. Every cursor move and redraw goes through it, so it is cached
+// and only rebuilt when the files or the filter change.
+type panelListing struct {
+	files   []ssh.FileInfo // All files of the directory
+	filter  panelFilter    // Filter indexes was built with
+	indexes []int          // Positions in files of the listed ones, nil when the filter is inactive
+}
+
+// len returns how many files are listed
+func (l *panelListing) len() int {
+	if l.indexes == nil {
+		return len(l.files)
+	}
+	return len(l.indexes)
+}
+
+// at returns the i-th listed file
+func (l *panelListing) at(i int) ssh.FileInfo {
+	if l.indexes == nil {
+		return l.files[i]
+	}
+	return l.files[l.indexes[i]]
+}
+
+// update rebuilds the listing unless it was built from the same files and filter
+func (l *panelListing) update(files []ssh.FileInfo, filter panelFilter) {
+	same := len(files) == len(l.files) && (len(files) == 0 || &files[0] == &l.files[0])
+	if same && filter == l.filter {
+		return
+	}
+
+	l.files, l.filter, l.indexes = files, filter, nil
+	if !filter.active() {
+		return
+	}
+	l.indexes = make([]int, 0, len(files))
+	for i, file := range files {
+		if filter.matches(file) {
+			l.indexes = append(l.indexes, i)
+		}
+	}
+}
+
+// filter returns the filter of a panel
+func (m *fileBrowserModel) filter(side PanelSide) *panelFilter {
+	if side == LeftPanel {
+		return &m.localFilter
+	}
+	return &m.remoteFilter
+}
+
+// handleFilter opens an input that narrows the focused panel's listing as it
+// is typed. Enter keeps the filter and esc restores the previous one.
+func (m *fileBrowserModel) handleFilter() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	filter := m.filter(side)
+	previous := filter.text
+
+	m.prompt = newPromptModel("Filter:", filter.text, func(value string) tea.Cmd {
+		return m.refreshPreviewCmd()
+	})
+	m.prompt.onChange = func(value string) {
+		filter.text = value
+		m.refilter(side, false)
+	}
+	m.prompt.onCancel = func() {
+		filter.text = previous
+		m.refilter(side, false)
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// handleToggleDotfiles shows or hides the dotfiles of the focused panel
+func (m *fileBrowserModel) handleToggleDotfiles() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	m.filter(side).hideDotfiles = !m.filter(side).hideDotfiles
+	m.refilter(side, true)
+	return m, m.refreshPreviewCmd()
+}
+
+// handleClearFilter removes the text filter of the focused panel
+func (m *fileBrowserModel) handleClearFilter() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	if m.filter(side).text == "" {
+		return m, nil
+	}
+	m.filter(side).text = ""
+	m.refilter(side, true)
+	return m, m.refreshPreviewCmd()
+}
+
+// refilter updates a panel after its filter changed. The cursor stays on the
+// same file if keepCursor is set and the file is still listed, and otherwise
+// moves to the first file.
+func (m *fileBrowserModel) refilter(side PanelSide, keepCursor bool) {
+	cursorName := ""
+	if file, ok := m.cursorFile(side); ok && keepCursor {
+		cursorName = file.Name
+	}
+
+	first := 0
+	if m.hasParentEntry(side) {
+		first = 1 // Skip the ".." entry
+	}
+	cursor := min(first, m.maxCursor(side))
+	if side == LeftPanel {
+		m.localCursor = cursor
+	} else {
+		m.remoteCursor = cursor
+	}
+
+	m.ensureCursorVisible(side)
+	if cursorName != "" {
+		m.placeCursor(side, cursorName)
+	}
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// findModel runs a recursive file search and lists the results as they arrive
+type findModel struct {
+	*resultList[ssh.FindResult]
+	selected map[int]bool
+}
+
+// Find message types
+type findJumpMsg struct {
+	side PanelSide
+	path string
+}
+
+type findCopyMsg struct {
+	side    PanelSide
+	results []ssh.FindResult
+}
+
+// newFindModel creates a search of root on one side
+func newFindModel(side PanelSide, root, query string, width, height int) *findModel {
+	return &findModel{
+		resultList: newResultList[ssh.FindResult](side, root, query, width, height),
+		selected:   make(map[int]bool),
+	}
+}
+
+// Init initializes the search results view
+func (m *findModel) Init() tea.Cmd {
+	return nil
+}
+
+// targets returns the selected results, or the one under the cursor
+func (m *findModel) targets() []ssh.FindResult {
+	var targets []ssh.FindResult
+	for i, result := range m.results {
+		if m.selected[i] {
+			targets = append(targets, result)
+		}
+	}
+	if len(targets) == 0 && m.cursor < len(m.results) {
+		targets = append(targets, m.results[m.cursor])
+	}
+	return targets
+}
+
+// Update handles messages for the search results view
+func (m *findModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case resultsMsg[ssh.FindResult]:
+		return m, m.receive(msg)
+
+	case tea.KeyMsg:
+		if cmd, ok := m.handleKey(msg.String()); ok {
+			return m, cmd
+		}
+		switch msg.String() {
+		case " ":
+			if m.cursor < len(m.results) {
+				m.selected[m.cursor] = !m.selected[m.cursor]
+				m.moveCursor(1)
+			}
+		case "enter":
+			if m.cursor < len(m.results) {
+				m.stop()
+				jump := findJumpMsg{side: m.side, path: m.results[m.cursor].Path}
+				return m, func() tea.Msg { return jump }
+			}
+		case "c":
+			if targets := m.targets(); len(targets) > 0 {
+				m.stop()
+				return m, func() tea.Msg { return findCopyMsg{side: m.side, results: targets} }
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the search results
+func (m *findModel) View() string {
+	extra := ""
+	if count := m.selectedCount(); count > 0 {
+		extra = fmt.Sprintf("%d selected", count)
+	}
+	help := "↑/↓/PgUp/PgDn: navigate • space: select • enter: go to file • c: copy to other panel • esc: stop/close • q: close"
+	return m.view("Find", "found", extra, help, m.renderResult)
+}
+
+// selectedCount returns how many results are selected
+func (m *findModel) selectedCount() int {
+	count := 0
+	for _, selected := range m.selected {
+		if selected {
+			count++
+		}
+	}
+	return count
+}
+
+// renderResult renders one result row with its path relative to the search root
+func (m *findModel) renderResult(i int) string {
+	result := m.results[i]
+
+	cursorIcon := " "
+	if i == m.cursor {
+		cursorIcon = ">"
+	}
+	selectIcon := " "
+	if m.selected[i] {
+		selectIcon = "✓"
+	}
+	fileType := "FILE"
+	if result.Info.IsLink {
+		fileType = "LINK"
+	} else if result.Info.IsDir {
+		fileType = "DIR"
+	}
+
+	line := fmt.Sprintf("%s %s %6s [%s] %s", cursorIcon, selectIcon, formatSize(result.Info.Size), fileType, m.relativePath(result.Path))
+	return m.rowStyle(i).Render(line)
+}
+
+// handleFind prompts for a search of the focused panel's directory tree
+func (m *fileBrowserModel) handleFind() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	root := m.panelPath(side)
+
+	m.prompt = newPromptModel("Find (glob or /regexp/, size>10M, mtime<7d):", "", func(value string) tea.Cmd {
+		query, _ := ssh.ParseFindQuery(value)
+		m.find = newFindModel(side, root, value, m.width, m.height)
+		m.mode = modeFind
+		client := m.sshClient
+		return m.find.start(func(ctx context.Context, found func(ssh.FindResult)) error {
+			if side == LeftPanel {
+				return ssh.FindLocal(ctx, root, query, found)
+			}
+			return client.Find(ctx, root, query, found)
+		})
+	})
+	m.prompt.validate = func(value string) error {
+		_, err := ssh.ParseFindQuery(value)
+		return err
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// jumpToFindResult shows the directory containing a search result with the cursor on it
+func (m *fileBrowserModel) jumpToFindResult(msg findJumpMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	m.focusedPanel = msg.side
+	if msg.side == LeftPanel {
+		return m, m.changeDir(msg.side, filepath.Dir(msg.path), filepath.Base(msg.path))
+	}
+	return m, m.changeDir(msg.side, path.Dir(msg.path), path.Base(msg.path))
+}
+
+// copyFindResultsCmd creates a command that copies search results into the other panel's directory
+func copyFindResultsCmd(client *ssh.Client, side PanelSide, results []ssh.FindResult, destDir string, policy ssh.SymlinkPolicy) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		for _, result := range results {
+			var err error
+			if side == LeftPanel {
+				err = client.UploadPath(result.Path, remotePathJoin(destDir, result.Info.Name), policy)
+			} else {
+				err = client.DownloadPath(result.Path, filepath.Join(destDir, result.Info.Name), policy)
+			}
+
+			if err != nil {
+				return errMsg{fmt.Errorf("failed to copy %s: %w", result.Path, err)}
+			}
+		}
+		return copyCompleteMsg{}
+	})
+}
@@ -0,0 +1,189 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCompletionHints limits how many candidates an ambiguous completion lists
+const maxCompletionHints = 20
+
+// goToCheckedMsg reports whether the path typed into a go-to prompt exists
+type goToCheckedMsg struct {
+	prompt *promptModel
+	side   PanelSide
+	path   string
+	isDir  bool
+	err    error
+}
+
+// handleGoTo prompts for a path to show in the focused panel. A file path
+// shows its directory with the cursor on the file.
+func (m *fileBrowserModel) handleGoTo() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	client, dir, home := m.sshClient, m.panelPath(side), m.remoteHome
+
+	var prompt *promptModel
+	prompt = newPromptModel("Go to path:", dir, func(value string) tea.Cmd {
+		return checkGoToCmd(client, prompt, side, resolvePath(side, dir, home, value))
+	})
+	prompt.validate = func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("path cannot be empty")
+		}
+		return nil
+	}
+	prompt.complete = func(value string) (string, []string) {
+		return completePath(client, side, dir, home, value)
+	}
+	m.prompt = prompt
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// checkGoToCmd creates a command that checks a go-to target exists
+func checkGoToCmd(client *ssh.Client, prompt *promptModel, side PanelSide, targetPath string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		msg := goToCheckedMsg{prompt: prompt, side: side, path: targetPath}
+		if side == LeftPanel {
+			var info os.FileInfo
+			if info, msg.err = os.Stat(targetPath); msg.err == nil {
+				msg.isDir = info.IsDir()
+			}
+		} else {
+			var info ssh.FileInfo
+			if info, msg.err = client.Stat(targetPath); msg.err == nil {
+				msg.isDir = info.IsDir
+			}
+		}
+		return msg
+	})
+}
+
+// finishGoTo shows a checked go-to target, or brings its prompt back with an
+// error so the path can be corrected
+func (m *fileBrowserModel) finishGoTo(msg goToCheckedMsg) (tea.Model, tea.Cmd) {
+	if msg.err == nil {
+		return m, m.goTo(msg.side, msg.path, msg.isDir)
+	}
+
+	err := fmt.Errorf("%s does not exist or can't be read", msg.path)
+	if m.mode != modeBrowse || m.prompt != msg.prompt {
+		return m, m.notify.Error(err)
+	}
+	m.prompt.err = err.Error()
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// goTo shows a directory in a panel, or the directory of a file with the cursor on it
+func (m *fileBrowserModel) goTo(side PanelSide, targetPath string, isDir bool) tea.Cmd {
+	dir, name := targetPath, ""
+	if !isDir {
+		if side == LeftPanel {
+			dir, name = filepath.Dir(targetPath), filepath.Base(targetPath)
+		} else {
+			dir, name = path.Dir(targetPath), path.Base(targetPath)
+		}
+	}
+
+	return m.changeDir(side, dir, name)
+}
+
+// resolvePath turns a path typed into a panel showing dir into an absolute
+// one, expanding ~ to the home directory and resolving relative paths against
+// dir. home is the remote home directory.
+func resolvePath(side PanelSide, dir, home, value string) string {
+	value = strings.TrimSpace(value)
+
+	if side == LeftPanel {
+		if value == "~" || strings.HasPrefix(value, "~/") || strings.HasPrefix(value, "~"+string(filepath.Separator)) {
+			if home, err := os.UserHomeDir(); err == nil {
+				value = filepath.Join(home, value[1:])
+			}
+		}
+		if !filepath.IsAbs(value) {
+			value = filepath.Join(dir, value)
+		}
+		return filepath.Clean(value)
+	}
+
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		value = path.Join(home, value[1:])
+	}
+	if !path.IsAbs(value) {
+		value = path.Join(dir, value)
+	}
+	return path.Clean(value)
+}
+
+// completePath completes the last element of a typed path from the entries of
+// its directory, resolved like resolvePath does. When several entries match,
+// it completes their common prefix and returns them as candidates.
+func completePath(client *ssh.Client, side PanelSide, dir, home, value string) (string, []string) {
+	separators := "/"
+	if side == LeftPanel {
+		separators += string(filepath.Separator)
+	}
+	split := strings.LastIndexAny(value, separators) + 1
+	dirPart, prefix := value[:split], value[split:]
+
+	var files []ssh.FileInfo
+	var err error
+	if side == LeftPanel {
+		files, err = ssh.ListLocalDir(resolvePath(side, dir, home, dirPart))
+	} else {
+		files, err = client.ListDir(resolvePath(side, dir, home, dirPart))
+	}
+	if err != nil {
+		return value, nil
+	}
+
+	var matches []ssh.FileInfo
+	for _, file := range files {
+		// Hidden entries are only offered once their dot has been typed
+		if strings.HasPrefix(file.Name, prefix) && (prefix != "" || !strings.HasPrefix(file.Name, ".")) {
+			matches = append(matches, file)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+
+	switch len(matches) {
+	case 0:
+		return value, nil
+	case 1:
+		completed := dirPart + matches[0].Name
+		if matches[0].IsDir {
+			completed += "/"
+		}
+		return completed, nil
+	}
+
+	common := matches[0].Name
+	candidates := make([]string, 0, min(len(matches), maxCompletionHints))
+	for i, match := range matches {
+		for !strings.HasPrefix(match.Name, common) {
+			common = common[:len(common)-1]
+		}
+		if i < maxCompletionHints {
+			name := match.Name
+			if match.IsDir {
+				name += "/"
+			}
+			candidates = append(candidates, name)
+		}
+	}
+	if len(matches) > maxCompletionHints {
+		candidates = append(candidates, fmt.Sprintf("(%d more)", len(matches)-maxCompletionHints))
+	}
+	// Trimming byte by byte may have cut a multi-byte character in half
+	return dirPart + strings.ToValidUTF8(common, ""), candidates
+}
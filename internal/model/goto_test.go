@@ -0,0 +1,116 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sshlepp/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCompletePath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"logs", "local.txt", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value, completed string
+		candidates       int
+	}{
+		{"da", "data/", 0},
+		{"lo", "lo", 2},
+		{"", "", 3},
+		{".h", ".hidden", 0},
+		{"missing/x", "missing/x", 0},
+	}
+	for _, test := range tests {
+		completed, candidates := completePath(nil, LeftPanel, dir, "", test.value)
+		if completed != test.completed || len(candidates) != test.candidates {
+			t.Errorf("completePath(%q) = %q, %q; expected %q with %d candidates", test.value, completed, candidates, test.completed, test.candidates)
+		}
+	}
+}
+
+func TestPromptCompletesInBackground(t *testing.T) {
+	prompt := newPromptModel("Go to path:", "da", nil)
+	prompt.complete = func(value string) (string, []string) { return value + "ta/", nil }
+
+	_, cmd := prompt.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if prompt.textInput.Value() != "da" || cmd == nil {
+		t.Fatal("Expected tab to complete in a command")
+	}
+	msg := cmd()
+
+	// A completion for a value that has since been edited is dropped
+	prompt.textInput.SetValue("dat")
+	prompt.Update(msg)
+	if prompt.textInput.Value() != "dat" {
+		t.Errorf("Expected a stale completion to be dropped, got %q", prompt.textInput.Value())
+	}
+
+	prompt.textInput.SetValue("da")
+	prompt.Update(msg)
+	if prompt.textInput.Value() != "data/" {
+		t.Errorf("Expected the completion to be applied, got %q", prompt.textInput.Value())
+	}
+}
+
+func TestGoToChecksInBackground(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestBrowser(1)
+	m.localPath = dir
+	m.handleGoTo()
+	prompt := m.prompt
+
+	// A missing path brings the prompt back with an error
+	_, check := m.Update(promptSubmittedMsg{value: "missing"})
+	_, cmd := m.Update(check())
+	if m.mode != modePrompt || m.prompt != prompt || prompt.err == "" || cmd == nil {
+		t.Fatalf("Expected the prompt to reopen with an error, got mode %v and error %q", m.mode, prompt.err)
+	}
+
+	m.mode = modeBrowse
+	msg := prompt.onSubmit("file.txt")().(goToCheckedMsg)
+	if msg.err != nil || msg.isDir || msg.path != filepath.Join(dir, "file.txt") {
+		t.Fatalf("Unexpected check result: %+v", msg)
+	}
+	m.Update(msg)
+	if m.mode != modeBrowse || m.localPath != dir || m.localCursorName != "file.txt" {
+		t.Errorf("Expected the panel to load the file's directory, got mode %v", m.mode)
+	}
+}
+
+func TestBookmarkCheckedWhilePickerOpen(t *testing.T) {
+	m := newTestBrowser(1)
+	m.config = &config.Config{}
+	m.bookmarks = newBookmarksModel(m.config, "host")
+	m.mode = modeBookmarks
+	bookmark := config.Bookmark{Name: "gone", LocalDir: filepath.Join(t.TempDir(), "gone")}
+
+	_, cmd := m.Update(bookmarkJumpMsg{bookmark: bookmark})
+	msg := cmd().(bookmarkCheckedMsg)
+	m.Update(msg)
+	if m.mode != modeBookmarks || m.bookmarks.err == "" {
+		t.Errorf("Expected the picker to stay open with an error, got mode %v and error %q", m.mode, m.bookmarks.err)
+	}
+
+	// Results for a picker that was closed in the meantime are ignored
+	m.bookmarks.err = ""
+	m.mode = modeBrowse
+	msg.err = nil
+	m.Update(msg)
+	if m.mode != modeBrowse || m.localPath != "/data" {
+		t.Error("Expected the result for a closed picker to be ignored")
+	}
+}
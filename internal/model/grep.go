@@ -0,0 +1,168 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// grepContextLines is how many lines before a match the preview of a result starts
+const grepContextLines = 3
+
+// grepModel runs a content search and lists the matching lines as they arrive
+type grepModel struct {
+	*resultList[ssh.GrepMatch]
+	pattern      *regexp.Regexp // Highlights the matches in each line
+	client       *ssh.Client
+	preview      *previewModel // File of the result under the cursor, nil while the list is shown
+	previewStart int           // Line the preview starts at
+}
+
+// newGrepModel creates a content search of root on one side
+func newGrepModel(client *ssh.Client, side PanelSide, root, query string, pattern *regexp.Regexp, width, height int) *grepModel {
+	return &grepModel{
+		resultList: newResultList[ssh.GrepMatch](side, root, query, width, height),
+		pattern:    pattern,
+		client:     client,
+	}
+}
+
+// Init initializes the content search view
+func (m *grepModel) Init() tea.Cmd {
+	return nil
+}
+
+// setSize resizes the results list and the preview
+func (m *grepModel) setSize(width, height int) {
+	m.resultList.setSize(width, height)
+	if m.preview != nil {
+		m.preview.setSize(width-2, height-4)
+	}
+}
+
+// openPreview shows the file of the result under the cursor starting just above the matching line
+func (m *grepModel) openPreview() tea.Cmd {
+	result := m.results[m.cursor]
+	m.previewStart = max(1, result.Line-grepContextLines)
+
+	m.preview = newPreviewModel(m.width-2, m.height-4)
+	m.preview.key = fmt.Sprintf("grep:%s:%d", result.Path, result.Line)
+	m.preview.name = fmt.Sprintf("%s:%d", m.relativePath(result.Path), result.Line)
+	m.preview.loading = true
+	return loadGrepPreviewCmd(m.client, m.side, result.Path, m.previewStart, m.preview.key, filepath.Base(result.Path))
+}
+
+// Update handles messages for the content search view
+func (m *grepModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case resultsMsg[ssh.GrepMatch]:
+		return m, m.receive(msg)
+
+	case previewLoadedMsg:
+		if m.preview != nil && msg.key == m.preview.key {
+			m.preview.setLoaded(msg)
+			if m.previewStart > 1 {
+				m.preview.info = fmt.Sprintf("%s from line %d", m.preview.info, m.previewStart)
+			}
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.preview != nil {
+			switch msg.String() {
+			case "esc", "q", "enter":
+				m.preview = nil
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.preview.viewport, cmd = m.preview.viewport.Update(msg)
+			return m, cmd
+		}
+
+		if cmd, ok := m.handleKey(msg.String()); ok {
+			return m, cmd
+		}
+		if msg.String() == "enter" && m.cursor < len(m.results) {
+			return m, m.openPreview()
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the matching lines, or the preview of one of them
+func (m *grepModel) View() string {
+	if m.preview != nil {
+		header := m.preview.headerView(m.preview.viewport.Width)
+		footer := m.preview.footerView(m.preview.viewport.Width)
+		help := ui.HelpStyle.Render("↑/↓/PgUp/PgDn: scroll • esc/q/enter: back to results")
+		return lipgloss.JoinVertical(lipgloss.Left, header, m.preview.View(), footer, help)
+	}
+
+	help := "↑/↓/PgUp/PgDn: navigate • enter: preview at line • esc: stop/close • q: close"
+	return m.view("Grep", "matches", "", help, m.renderResult)
+}
+
+// renderResult renders one matching line prefixed with its file and line number
+func (m *grepModel) renderResult(i int) string {
+	result := m.results[i]
+
+	cursorIcon := " "
+	if i == m.cursor {
+		cursorIcon = ">"
+	}
+	location := fmt.Sprintf("%s %s:%d:", cursorIcon, m.relativePath(result.Path), result.Line)
+
+	text := ui.HighlightMatches(ui.SanitizeLine(strings.TrimSpace(result.Text)), m.pattern)
+	line := m.rowStyle(i).Render(location) + " " + text
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+}
+
+// handleGrep prompts for a content search of the focused panel's directory tree
+func (m *fileBrowserModel) handleGrep() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	root := m.panelPath(side)
+
+	m.prompt = newPromptModel("Search file contents (text or /regexp/):", "", func(value string) tea.Cmd {
+		query, _ := ssh.ParseGrepQuery(value)
+		m.grep = newGrepModel(m.sshClient, side, root, value, query.Regexp, m.width, m.height)
+		m.mode = modeGrep
+		client := m.sshClient
+		return m.grep.start(func(ctx context.Context, found func(ssh.GrepMatch)) error {
+			if side == LeftPanel {
+				return ssh.GrepLocal(ctx, root, query, found)
+			}
+			return client.Grep(ctx, root, query, found)
+		})
+	})
+	m.prompt.validate = func(value string) error {
+		_, err := ssh.ParseGrepQuery(value)
+		return err
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// loadGrepPreviewCmd creates a command to read a file from a line on for the preview
+func loadGrepPreviewCmd(client *ssh.Client, side PanelSide, fullPath string, line int, key, name string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		var data []byte
+		var truncated bool
+		var err error
+		if side == LeftPanel {
+			data, truncated, err = ssh.ReadLocalFileFromLine(fullPath, line, previewBytes)
+		} else {
+			data, truncated, err = client.ReadFileFromLine(fullPath, line, previewBytes)
+		}
+
+		return previewLoadedMsg{key: key, name: name, data: data, truncated: truncated, err: err}
+	})
+}
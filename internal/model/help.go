@@ -0,0 +1,157 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"sshlepp/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// shortHelp is the help line below the panels, listing only the common keys.
+// ? opens the full list.
+const shortHelp = "?: all keys • tab: switch panel • ←/→: go up/into dir • space: select • c: copy • m: move • d: delete • e: edit • /: filter • g: go to • q: quit"
+
+// keyHelp describes what a key does
+type keyHelp struct {
+	keys   string
+	action string
+}
+
+// keyHelpGroup is a titled group of keys in the help overlay
+type keyHelpGroup struct {
+	title string
+	keys  []keyHelp
+}
+
+// keyHelpGroups returns every key of the file browser, grouped for the help overlay
+func (m *fileBrowserModel) keyHelpGroups() []keyHelpGroup {
+	return []keyHelpGroup{
+		{"Navigate", []keyHelp{
+			{"tab", "switch panel"},
+			{"↑/↓ PgUp/PgDn", "move the cursor"},
+			{"Home/End", "first/last file"},
+			{"←/→ enter", "go up/into dir or archive"},
+			{"[/]", "back/forward in history"},
+			{"g", "go to a path"},
+			{"b/B", "bookmarks/add bookmark"},
+			{"r", "refresh"},
+		}},
+		{"Select", []keyHelp{
+			{"space", "select file"},
+			{"shift+↑/↓", "select a range"},
+			{"a/*/-", "all/invert/none"},
+			{"+", "select by glob"},
+			{"y", "add to basket"},
+			{"Y", fmt.Sprintf("open basket (%d)", len(m.basket.items))},
+		}},
+		{"Files", []keyHelp{
+			{"c", "copy to other panel"},
+			{"m", "move to other panel"},
+			{"M", "move on the same side"},
+			{"d", "delete"},
+			{"R", "rename"},
+			{"n", "new directory"},
+			{"p", "permissions"},
+			{"e", "edit"},
+			{"L", fmt.Sprintf("links: %s", m.symlinkPolicy)},
+		}},
+		{"View", []keyHelp{
+			{"v/V", "preview/pager"},
+			{"t", "follow a remote file"},
+			{"s/o/D", "sort/reverse/dirs first"},
+			{"/ esc", "filter/clear filter"},
+			{".", "dotfiles"},
+			{"C", "columns"},
+			{"E", "notifications"},
+		}},
+		{"Search", []keyHelp{
+			{"f", "find files"},
+			{"F", "search contents"},
+		}},
+		{"Remote", []keyHelp{
+			{"S", "shell"},
+			{"!", "run a command"},
+			{"z/Z", "tar download/extract"},
+			{"u", "tar upload"},
+			{"x", "extract archive here"},
+		}},
+		{"Other", []keyHelp{
+			{"?", "this help"},
+			{"q", "quit"},
+		}},
+	}
+}
+
+// renderKeyHelpGroup renders a group as its title above one line per key
+func renderKeyHelpGroup(group keyHelpGroup) []string {
+	keyWidth := 0
+	for _, key := range group.keys {
+		keyWidth = max(keyWidth, lipgloss.Width(key.keys))
+	}
+
+	lines := []string{ui.HeaderStyle.Render(group.title)}
+	for _, key := range group.keys {
+		padding := strings.Repeat(" ", keyWidth-lipgloss.Width(key.keys))
+		lines = append(lines, ui.InfoStyle.Render(key.keys)+padding+"  "+key.action)
+	}
+	return append(lines, "")
+}
+
+// packColumns splits groups of lines, in order, into at most count columns,
+// keeping the longest column as short as possible
+func packColumns(groups [][]string, count int) [][]string {
+	total := 0
+	for _, lines := range groups {
+		total += len(lines)
+	}
+	for target := (total + count - 1) / count; ; target++ {
+		columns := [][]string{nil}
+		for _, lines := range groups {
+			last := len(columns) - 1
+			if len(columns[last]) > 0 && len(columns[last])+len(lines) > target {
+				columns = append(columns, nil)
+				last++
+			}
+			columns[last] = append(columns[last], lines...)
+		}
+		if len(columns) <= count {
+			return columns
+		}
+	}
+}
+
+// helpView renders all keys as a dialog, with the groups laid out in as many
+// columns as fit in width
+func (m *fileBrowserModel) helpView(width int) string {
+	var groups [][]string
+	for _, group := range m.keyHelpGroups() {
+		groups = append(groups, renderKeyHelpGroup(group))
+	}
+
+	var body string
+	for count := len(groups); count >= 1; count-- {
+		columns := packColumns(groups, count)
+		rendered := make([]string, len(columns))
+		for i, column := range columns {
+			rendered[i] = lipgloss.NewStyle().PaddingRight(3).Render(strings.Join(column, "\n"))
+		}
+		body = lipgloss.JoinHorizontal(lipgloss.Top, rendered...)
+		if lipgloss.Width(body) <= width-6 { // Dialog border and padding
+			break
+		}
+	}
+
+	return ui.DialogStyle.Render(strings.TrimRight(body, " \n") + "\n" + ui.HelpStyle.Render("esc: close"))
+}
+
+// updateHelp handles keys while the help overlay is open
+func (m *fileBrowserModel) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "?":
+		m.mode = modeBrowse
+	}
+	return m, nil
+}
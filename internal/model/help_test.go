@@ -0,0 +1,50 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestHelpLineFitsOneLine(t *testing.T) {
+	m := newTestBrowser(3)
+	for _, width := range []int{60, 100, 200} {
+		m.width = width
+		m.resizePanels()
+		view := m.View()
+		lines := strings.Split(view, "\n")
+		help := lines[len(lines)-1]
+		if lipgloss.Width(help) > width || !strings.Contains(help, "?") {
+			t.Errorf("Expected a help line of at most %d cells pointing to ?, got %q", width, help)
+		}
+		if lipgloss.Height(view) > m.height {
+			t.Errorf("Expected the view to fit %d lines at width %d, got %d", m.height, width, lipgloss.Height(view))
+		}
+	}
+}
+
+func TestHelpOverlay(t *testing.T) {
+	m := newTestBrowser(3)
+	m.basket.add(newBasketItem(LeftPanel, "/data/a.log"))
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if m.mode != modeHelp || !m.capturesInput() {
+		t.Fatalf("Expected ? to open the help, got mode %v", m.mode)
+	}
+
+	view := m.View()
+	for _, want := range []string{"open basket (1)", "move on the same side", "columns", "esc: close"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the help to list %q:\n%s", want, view)
+		}
+	}
+	if lipgloss.Width(view) > m.width {
+		t.Errorf("Expected the help to fit in %d cells, got %d", m.width, lipgloss.Width(view))
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != modeBrowse {
+		t.Errorf("Expected esc to close the help, got mode %v", m.mode)
+	}
+}
@@ -0,0 +1,194 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxHistory limits how many directories each panel can go back to
+const maxHistory = 100
+
+// historyEntry is a directory a panel has shown, with the file the cursor was on
+type historyEntry struct {
+	path   string
+	cursor string
+}
+
+// dirHistory holds the directories a panel can go back and forward to
+type dirHistory struct {
+	back    []historyEntry
+	forward []historyEntry
+}
+
+// visit records leaving a directory for a new one, which starts a new forward history
+func (h *dirHistory) visit(from historyEntry) {
+	h.back = append(h.back, from)
+	if len(h.back) > maxHistory {
+		h.back = h.back[len(h.back)-maxHistory:]
+	}
+	h.forward = nil
+}
+
+// goBack returns the previous directory, remembering current to go forward to
+func (h *dirHistory) goBack(current historyEntry) (historyEntry, bool) {
+	if len(h.back) == 0 {
+		return historyEntry{}, false
+	}
+	entry := h.back[len(h.back)-1]
+	h.back = h.back[:len(h.back)-1]
+	h.forward = append(h.forward, current)
+	return entry, true
+}
+
+// goForward returns the directory last gone back from, remembering current to go back to
+func (h *dirHistory) goForward(current historyEntry) (historyEntry, bool) {
+	if len(h.forward) == 0 {
+		return historyEntry{}, false
+	}
+	entry := h.forward[len(h.forward)-1]
+	h.forward = h.forward[:len(h.forward)-1]
+	h.back = append(h.back, current)
+	return entry, true
+}
+
+// history returns the directory history of a panel
+func (m *fileBrowserModel) history(side PanelSide) *dirHistory {
+	if side == LeftPanel {
+		return &m.localHistory
+	}
+	return &m.remoteHistory
+}
+
+// currentEntry returns the directory a panel shows and the file under its cursor
+func (m *fileBrowserModel) currentEntry(side PanelSide) historyEntry {
+	entry := historyEntry{path: m.panelPath(side)}
+	if file, ok := m.cursorFile(side); ok {
+		entry.cursor = file.Name
+	}
+	return entry
+}
+
+// changeDir shows another directory in a panel, recording the current one in
+// its history. cursorName is the file to put the cursor on, if any.
+func (m *fileBrowserModel) changeDir(side PanelSide, dir, cursorName string) tea.Cmd {
+	if dir != m.panelPath(side) {
+		m.history(side).visit(m.currentEntry(side))
+	}
+	m.setDir(side, dir, cursorName)
+	return m.loadPanelCmd(side)
+}
+
+// setDir points a panel at a directory without touching its history
+func (m *fileBrowserModel) setDir(side PanelSide, dir, cursorName string) {
+	// A paired bookmark can move a panel that is showing an archive
+	m.closeArchive(side)
+	m.resetSelection(side)
+	if side == LeftPanel {
+		m.localPath = dir
+		m.localCursor = 0
+		m.localCursorName = cursorName
+	} else {
+		m.remotePath = dir
+		m.remoteCursor = 0
+		m.remoteCursorName = cursorName
+	}
+}
+
+// goUp shows the parent directory of a panel with the cursor on the directory it came out of
+func (m *fileBrowserModel) goUp(side PanelSide) tea.Cmd {
+	if side == LeftPanel {
+		return m.changeDir(side, filepath.Dir(m.localPath), filepath.Base(m.localPath))
+	}
+	return m.changeDir(side, remotePathDir(m.remotePath), path.Base(m.remotePath))
+}
+
+// handleHistory goes back or forward in the focused panel's directory history
+func (m *fileBrowserModel) handleHistory(back bool) (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	history := m.history(side)
+
+	var entry historyEntry
+	var ok bool
+	if back {
+		entry, ok = history.goBack(m.currentEntry(side))
+	} else {
+		entry, ok = history.goForward(m.currentEntry(side))
+	}
+	if !ok {
+		return m, nil
+	}
+
+	m.setDir(side, entry.path, entry.cursor)
+	return m, m.loadPanelCmd(side)
+}
+
+// locationSaveDelay is how long the panels must stay in their directories
+// before the location is saved
+const locationSaveDelay = 2 * time.Second
+
+// locationSaveMsg saves the remembered location, unless it changed again since
+type locationSaveMsg struct {
+	generation int
+}
+
+// rememberLocation records the directories shown for the connected host so the
+// next session starts there. They are saved once they stay put for
+// locationSaveDelay, so walking through directories doesn't rewrite the
+// config at every step.
+func (m *fileBrowserModel) rememberLocation() tea.Cmd {
+	if m.config == nil || m.hostName == "" {
+		return nil
+	}
+
+	state := m.config.Host(m.hostName)
+	if state.LocalDir == m.localPath && state.RemoteDir == m.remotePath {
+		return nil
+	}
+	state.LocalDir = m.localPath
+	state.RemoteDir = m.remotePath
+	m.locationUnsaved = true
+	m.locationGeneration++
+	generation := m.locationGeneration
+	return tea.Tick(locationSaveDelay, func(time.Time) tea.Msg {
+		return locationSaveMsg{generation: generation}
+	})
+}
+
+// handleLocationSave saves the remembered location once it has stayed put
+func (m *fileBrowserModel) handleLocationSave(msg locationSaveMsg) tea.Cmd {
+	if msg.generation != m.locationGeneration {
+		return nil // Moved on since, a later save is scheduled
+	}
+	if err := m.saveLocation(); err != nil {
+		return m.notify.Warn(fmt.Sprintf("Location won't be remembered: %s", err))
+	}
+	return nil
+}
+
+// saveLocation writes a remembered location that hasn't been saved yet
+func (m *fileBrowserModel) saveLocation() error {
+	if !m.locationUnsaved {
+		return nil
+	}
+	m.locationUnsaved = false
+	return m.config.Save()
+}
+
+// restoreLocation returns the remembered directory if it still exists, or fallback
+func restoreLocation(remembered, fallback string, isDir func(string) bool) string {
+	if remembered != "" && isDir(remembered) {
+		return remembered
+	}
+	return fallback
+}
+
+// isLocalDir reports whether a local path is a directory
+func isLocalDir(localPath string) bool {
+	info, err := os.Stat(localPath)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sshlepp/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRememberLocation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTestBrowser(1)
+	m.config, m.hostName = cfg, "server"
+
+	// Walking through directories only schedules a save
+	first := m.rememberLocation()
+	m.localPath = "/data/logs"
+	second := m.rememberLocation()
+	if first == nil || second == nil {
+		t.Fatal("Expected each change to schedule a save")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("Expected nothing to be written while moving around")
+	}
+
+	// Only the last scheduled save writes the config
+	m.Update(locationSaveMsg{generation: 1})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("Expected a superseded save to be skipped")
+	}
+	m.Update(locationSaveMsg{generation: 2})
+	if loaded, err := config.Load(path); err != nil || loaded.Host("server").LocalDir != "/data/logs" {
+		t.Fatalf("Expected the location to be saved, got %v", err)
+	}
+
+	// An unchanged location isn't scheduled again
+	if cmd := m.rememberLocation(); cmd != nil {
+		t.Error("Expected an unchanged location not to be saved")
+	}
+
+	// A failed save is reported
+	blocker := filepath.Join(dir, "blocker")
+	m.config, err = config.Load(filepath.Join(blocker, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.rememberLocation()
+	m.Update(locationSaveMsg{generation: m.locationGeneration})
+	if len(m.notify.Toasts()) != 1 {
+		t.Error("Expected a warning when the location can't be saved")
+	}
+}
+
+func TestQuitSavesLocation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := newTestBrowser(1)
+	browser.config, browser.hostName = cfg, "server"
+	browser.rememberLocation()
+
+	m := &mainModel{state: StateFileBrowser, fileBrowser: browser, config: cfg, notifier: browser.notify}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if loaded, err := config.Load(path); err != nil || loaded.Host("server").LocalDir != "/data" {
+		t.Errorf("Expected quitting to save the location, got %v", err)
+	}
+}
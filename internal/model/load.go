@@ -0,0 +1,208 @@
+package model
+
+import (
+	"context"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dirLoad is a directory listing being loaded into a panel
+type dirLoad struct {
+	side        PanelSide
+	path        string
+	order       ssh.SortOrder  // Order files is sorted in
+	files       []ssh.FileInfo // Entries received so far
+	progressive bool           // Show entries as they arrive, as the panel lists another directory
+	cancel      context.CancelFunc
+	pages       chan []ssh.FileInfo
+	err         error // Set by the listing before pages is closed
+}
+
+// panelLoadState tracks the loading of a panel's listing
+type panelLoadState struct {
+	load      *dirLoad // Listing in progress, nil when none
+	err       error    // Why the last listing failed, shown in the panel
+	shownPath string   // Directory the listed files belong to
+}
+
+// panelFilesMsg delivers a page of a directory listing
+type panelFilesMsg struct {
+	load  *dirLoad
+	files []ssh.FileInfo
+	done  bool
+}
+
+// newLoadSpinner creates the spinner shown in panels while they load
+func newLoadSpinner() spinner.Model {
+	return spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(ui.HelpStyle))
+}
+
+// loadState returns the loading state of a panel
+func (m *fileBrowserModel) loadState(side PanelSide) *panelLoadState {
+	if side == LeftPanel {
+		return &m.localLoad
+	}
+	return &m.remoteLoad
+}
+
+// loading reports whether any panel is loading its listing
+func (m *fileBrowserModel) loading() bool {
+	return m.localLoad.load != nil || m.remoteLoad.load != nil
+}
+
+// loadPanelsCmd reloads the listings of both panels independently
+func (m *fileBrowserModel) loadPanelsCmd() tea.Cmd {
+	return tea.Batch(m.loadPanelCmd(LeftPanel), m.loadPanelCmd(RightPanel))
+}
+
+// loadPanelCmd starts loading the listing of a panel's directory, cancelling
+// any listing of that panel still in progress. A panel that moved to another
+// directory is emptied and filled as pages arrive, while a refreshed panel
+// keeps its files until the new listing is complete.
+func (m *fileBrowserModel) loadPanelCmd(side PanelSide) tea.Cmd {
+	state := m.loadState(side)
+	if state.load != nil {
+		state.load.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	load := &dirLoad{
+		side:        side,
+		path:        m.panelPath(side),
+		order:       *m.sortOrder(side),
+		progressive: state.shownPath != m.panelPath(side),
+		cancel:      cancel,
+		pages:       make(chan []ssh.FileInfo, 1),
+	}
+	state.load = load
+	if load.progressive {
+		// Nothing selected or filtered in the old listing applies to the new one
+		state.err = nil
+		m.resetSelection(side)
+		m.showFiles(side, nil)
+	}
+
+	return tea.Batch(listDirCmd(ctx, m.sshClient, load), waitForPageCmd(load), m.spinner.Tick)
+}
+
+// listDirCmd creates a command that lists a directory, feeding its pages to the load
+func listDirCmd(ctx context.Context, client *ssh.Client, load *dirLoad) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		page := func(files []ssh.FileInfo) {
+			select {
+			case load.pages <- files:
+			case <-ctx.Done():
+			}
+		}
+
+		var err error
+		if load.side == LeftPanel {
+			var files []ssh.FileInfo
+			if files, err = ssh.ListLocalDir(load.path); err == nil {
+				page(files)
+			}
+		} else {
+			err = client.ListDirPages(ctx, load.path, page)
+		}
+		if err != nil && ctx.Err() == nil {
+			load.err = err
+		}
+		close(load.pages)
+		return nil
+	})
+}
+
+// waitForPageCmd waits for the next page of a listing
+func waitForPageCmd(load *dirLoad) tea.Cmd {
+	return func() tea.Msg {
+		files, ok := <-load.pages
+		return panelFilesMsg{load: load, files: files, done: !ok}
+	}
+}
+
+// receiveFiles adds a page of a listing to its panel, or finishes the
+// listing once all pages arrived
+func (m *fileBrowserModel) receiveFiles(msg panelFilesMsg) tea.Cmd {
+	load := msg.load
+	state := m.loadState(load.side)
+	if load != state.load {
+		return nil // Superseded by another listing of the panel
+	}
+
+	if !msg.done {
+		// The order may have changed while the listing was loading
+		order := *m.sortOrder(load.side)
+		if order != load.order {
+			ssh.SortFiles(load.files, order)
+			load.order = order
+		}
+		load.files = ssh.MergeFiles(load.files, msg.files, order)
+		if load.progressive {
+			m.showFiles(load.side, load.files)
+		}
+		return waitForPageCmd(load)
+	}
+
+	state.load = nil
+	state.err = load.err
+	state.shownPath = load.path
+	if load.err != nil && !load.progressive {
+		// Keep showing the files from before the failed refresh
+		return nil
+	}
+	m.showFiles(load.side, load.files)
+
+	// Drop selected files that are gone and count the new sizes of the rest
+	if selected := m.selection(load.side); selected.len() > 0 {
+		kept := newFileSelection()
+		for _, file := range load.files {
+			if selected.has(file.Name) {
+				kept.add(file)
+			}
+		}
+		*selected = *kept
+	}
+	if load.side == LeftPanel {
+		m.localCursorName = ""
+	} else {
+		m.remoteCursorName = ""
+	}
+
+	var remember tea.Cmd
+	if load.err == nil {
+		remember = m.rememberLocation()
+	}
+	// Files may have changed on disk, so reload the preview too
+	m.preview.key = ""
+	return tea.Batch(remember, m.refreshPreviewCmd())
+}
+
+// showFiles replaces the files listed in a panel, which must be in the
+// panel's sort order, keeping the cursor on the same file where possible
+func (m *fileBrowserModel) showFiles(side PanelSide, files []ssh.FileInfo) {
+	cursorName := m.localCursorName
+	if side == RightPanel {
+		cursorName = m.remoteCursorName
+	}
+	if file, ok := m.cursorFile(side); ok && cursorName == "" {
+		cursorName = file.Name
+	}
+
+	m.invalidateListing(side)
+	if side == LeftPanel {
+		m.localFiles = files
+		// Keep the cursor in range when entries disappeared (e.g. after a delete)
+		m.localCursor = min(m.localCursor, m.maxCursor(side))
+	} else {
+		m.remoteFiles = files
+		m.remoteCursor = min(m.remoteCursor, m.maxCursor(side))
+	}
+	if cursorName != "" {
+		m.placeCursor(side, cursorName)
+	}
+	m.ensureCursorVisible(side)
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// finishLoad runs a panel's listing to completion, handing each page to the browser
+func finishLoad(m *fileBrowserModel, side PanelSide) {
+	load := m.loadState(side).load
+	go listDirCmd(context.Background(), m.sshClient, load)()
+	for {
+		msg := waitForPageCmd(load)().(panelFilesMsg)
+		m.receiveFiles(msg)
+		if msg.done {
+			return
+		}
+	}
+}
+
+func TestLoadPanel(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestBrowser(0)
+	m.setDir(LeftPanel, dir, "b.txt")
+	m.loadPanelCmd(LeftPanel)
+	stale := m.localLoad.load
+	m.loadPanelCmd(LeftPanel)
+	if !stale.progressive {
+		t.Error("Expected a listing of another directory to show pages as they arrive")
+	}
+
+	// Pages of a superseded listing are ignored
+	m.receiveFiles(panelFilesMsg{load: stale, done: true})
+	if m.localLoad.load == nil {
+		t.Fatal("Expected the newer listing to still be loading")
+	}
+
+	finishLoad(m, LeftPanel)
+	var names []string
+	for _, file := range m.localFiles {
+		names = append(names, file.Name)
+	}
+	if got := strings.Join(names, " "); got != "sub a.txt b.txt" {
+		t.Errorf("Expected the sorted listing, got %q", got)
+	}
+	if file, ok := m.cursorFile(LeftPanel); !ok || file.Name != "b.txt" {
+		t.Errorf("Expected the cursor on b.txt, got %q", file.Name)
+	}
+	if m.loading() || m.localLoad.err != nil {
+		t.Errorf("Expected the listing to be done without error, got %v", m.localLoad.err)
+	}
+
+	// A failed refresh keeps the files and reports the error in the panel
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	m.loadPanelCmd(LeftPanel)
+	finishLoad(m, LeftPanel)
+	if m.localLoad.err == nil {
+		t.Error("Expected the failed listing to be reported")
+	}
+	if len(m.localFiles) != 3 {
+		t.Errorf("Expected the previous files to stay listed, got %d", len(m.localFiles))
+	}
+	if !strings.Contains(m.renderPanelBody(LeftPanel), "failed to list") {
+		t.Error("Expected the error to be shown in the panel")
+	}
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 
+	"sshlepp/internal/config"
 	"sshlepp/internal/ssh"
 	"sshlepp/internal/ui"
 
@@ -27,8 +28,10 @@ type mainModel struct {
 	passwordInput *passwordInputModel
 	fileBrowser   *fileBrowserModel
 	copyProgress  *copyProgressModel
+	config        *config.Config
 	width, height int
-	error         error
+	notifier      *ui.Notifier // Shared with the file browser
+	configErr     error        // Why the config couldn't be loaded, warned about on start
 }
 
 // NewMainModel creates a new main model
@@ -38,14 +41,39 @@ func NewMainModel() (*mainModel, error) {
 		return nil, fmt.Errorf("failed to parse SSH config: %w", err)
 	}
 
+	// Without a config directory nothing is remembered between runs
+	cfg := &config.Config{}
+	var cfgErr error
+	if path, err := config.DefaultPath(); err == nil {
+		cfg, cfgErr = loadConfig(path)
+	}
+
 	return &mainModel{
 		state:        StateServerSelect,
 		serverSelect: newServerSelectModel(hosts),
+		config:       cfg,
+		notifier:     ui.NewNotifier(),
+		configErr:    cfgErr,
 	}, nil
 }
 
+// loadConfig reads the config file at path. When it can't be read, the error
+// is returned along with an empty config that is never saved, leaving the
+// file as it is for the user to fix.
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return &config.Config{}, err
+	}
+	return cfg, nil
+}
+
 // Init initializes the main model
 func (m *mainModel) Init() tea.Cmd {
+	if m.configErr != nil {
+		warning := fmt.Sprintf("Settings won't be remembered this session: %s", m.configErr)
+		return tea.Batch(m.serverSelect.Init(), m.notifier.Warn(warning))
+	}
 	return m.serverSelect.Init()
 }
 
@@ -70,21 +98,28 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
+		case "ctrl+c":
+			return m, m.quit()
+		case "q":
+			if !m.capturesInput() {
+				return m, m.quit()
+			}
 		}
 
+	case ui.DismissMsg:
+		m.notifier.Dismiss(msg.ID)
+		return m, nil
+
 	case PasswordEnteredMsg:
 		// Try to create SSH client with password
 		client, err := ssh.NewClientWithPassphrase(*msg.Host, msg.Password)
 		if err != nil {
-			m.error = fmt.Errorf("authentication failed: %w", err)
 			m.state = StateServerSelect
-			return m, nil
+			return m, m.notifier.Error(fmt.Errorf("authentication failed: %w", err))
 		}
 
 		m.state = StateFileBrowser
-		m.fileBrowser, cmd = newFileBrowserModel(client, msg.Host, m.width, m.height)
+		m.fileBrowser, cmd = newFileBrowserModel(client, msg.Host, m.config, m.notifier, m.width, m.height)
 		return m, cmd
 
 	case PasswordCancelledMsg:
@@ -110,7 +145,7 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.state = StateFileBrowser
-			m.fileBrowser, cmd = newFileBrowserModel(client, m.serverSelect.selectedHost, m.width, m.height)
+			m.fileBrowser, cmd = newFileBrowserModel(client, m.serverSelect.selectedHost, m.config, m.notifier, m.width, m.height)
 			return m, cmd
 		}
 
@@ -131,17 +166,35 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-// View renders the main model
-func (m *mainModel) View() string {
-	if m.error != nil {
-		return ui.ErrorStyle.Render(fmt.Sprintf("Error: %s", m.error.Error()))
+// quit ends the program, first saving a location the file browser hasn't
+// saved yet. A failure has nowhere to be shown any more, and was already
+// warned about if saving failed during the session.
+func (m *mainModel) quit() tea.Cmd {
+	if m.fileBrowser != nil {
+		m.fileBrowser.saveLocation()
 	}
+	return tea.Quit
+}
+
+// capturesInput reports whether the active screen is reading text input,
+// in which case "q" is typed rather than quitting
+func (m *mainModel) capturesInput() bool {
+	switch m.state {
+	case StatePasswordInput:
+		return true
+	case StateFileBrowser:
+		return m.fileBrowser != nil && m.fileBrowser.capturesInput()
+	}
+	return false
+}
 
+// View renders the main model
+func (m *mainModel) View() string {
 	switch m.state {
 	case StateServerSelect:
-		return m.serverSelect.View()
+		return m.withToasts(m.serverSelect.View())
 	case StatePasswordInput:
-		return m.passwordInput.View()
+		return m.withToasts(m.passwordInput.View())
 	case StateFileBrowser:
 		return m.fileBrowser.View()
 	case StateCopying:
@@ -150,3 +203,13 @@ func (m *mainModel) View() string {
 		return ""
 	}
 }
+
+// withToasts adds the notifications on screen below a view. The file browser
+// shows them itself, in place of its help.
+func (m *mainModel) withToasts(view string) string {
+	toasts := m.notifier.ToastView(m.width)
+	if toasts == "" {
+		return view
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, view, "", toasts)
+}
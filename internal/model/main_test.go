@@ -0,0 +1,28 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err == nil || cfg == nil {
+		t.Fatalf("Expected an empty config and an error, got %v, %v", cfg, err)
+	}
+
+	// The corrupt file is left alone for the user to fix
+	cfg.Host("server").LocalDir = "/tmp"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{not json" {
+		t.Errorf("Expected the config file to be untouched, got %q", data)
+	}
+}
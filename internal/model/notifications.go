@@ -0,0 +1,26 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateErrorLog handles keys while the notification log is open
+func (m *fileBrowserModel) updateErrorLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Page through the log by about as many entries as it shows
+	page := max(1, m.height-6)
+	switch msg.String() {
+	case "up", "k":
+		m.notify.ScrollLog(-1, m.width, m.height)
+	case "down", "j":
+		m.notify.ScrollLog(1, m.width, m.height)
+	case "pgup":
+		m.notify.ScrollLog(-page, m.width, m.height)
+	case "pgdown":
+		m.notify.ScrollLog(page, m.width, m.height)
+	case "c":
+		m.notify.ClearLog()
+	case "esc", "q", "E":
+		m.mode = modeBrowse
+	}
+	return m, nil
+}
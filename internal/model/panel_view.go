@@ -0,0 +1,195 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// panelView is the visible window onto a panel's listing. Only the rows inside
+// it are rendered, so drawing a panel and moving its cursor cost the same
+// whatever the size of the directory.
+type panelView struct {
+	width  int
+	height int // Lines of the panel body, including the path line
+	offset int // First visible row of the listing
+}
+
+// panelBodyHeader is the number of lines above the listing: the path and a
+// line that is blank unless listing the directory failed
+const panelBodyHeader = 2
+
+// rows returns how many listing rows fit in the view
+func (v *panelView) rows() int {
+	return max(1, v.height-panelBodyHeader)
+}
+
+// window returns the range of rows to show out of total with the cursor on
+// row cursor, moving as little as possible from the current offset. It follows
+// the cursor even before the offset is updated, e.g. after a reload shortened
+// the listing.
+func (v *panelView) window(cursor, total int) (start, end int) {
+	start = v.offset
+	if cursor < start {
+		start = cursor
+	} else if cursor >= start+v.rows() {
+		start = cursor - v.rows() + 1
+	}
+	start = max(0, min(start, total-v.rows()))
+	return start, min(total, start+v.rows())
+}
+
+// scrollPercent returns how far down the listing the window is, from 0 to 1
+func (v *panelView) scrollPercent(cursor, total int) float64 {
+	if total <= v.rows() {
+		return 1
+	}
+	start, _ := v.window(cursor, total)
+	return float64(start) / float64(total-v.rows())
+}
+
+// view returns the visible window of a panel
+func (m *fileBrowserModel) view(side PanelSide) *panelView {
+	if side == LeftPanel {
+		return &m.leftView
+	}
+	return &m.rightView
+}
+
+// panelSize returns the outer width of a panel and the height of its body
+func (m *fileBrowserModel) panelSize() (width, height int) {
+	headerHeight := 3                                   // Header with path info
+	footerHeight := 2                                   // Footer with scroll info
+	width = (m.width - 4) / 2                           // Account for borders and spacing
+	height = m.height - headerHeight - footerHeight - 4 // Account for help text
+	return width, height
+}
+
+// resizePanels fits the panels and the preview to the window size
+func (m *fileBrowserModel) resizePanels() {
+	panelWidth, panelHeight := m.panelSize()
+	for _, side := range []PanelSide{LeftPanel, RightPanel} {
+		view := m.view(side)
+		view.width = panelWidth - 2 // Account for padding
+		view.height = panelHeight
+		m.ensureCursorVisible(side)
+	}
+	if m.preview == nil {
+		m.preview = newPreviewModel(panelWidth-2, panelHeight)
+	} else if m.mode != modePager {
+		m.preview.setSize(panelWidth-2, panelHeight)
+	}
+	m.ready = true
+}
+
+// ensureCursorVisible scrolls a panel so that its cursor is in view
+func (m *fileBrowserModel) ensureCursorVisible(side PanelSide) {
+	view := m.view(side)
+	view.offset, _ = view.window(m.cursor(side), m.listingRows(side))
+}
+
+// listingRows returns the number of rows of a panel, counting the ".." entry
+func (m *fileBrowserModel) listingRows(side PanelSide) int {
+	rows := m.panelFiles(side).len()
+	if m.hasParentEntry(side) {
+		rows++
+	}
+	return rows
+}
+
+// renderPanelBody renders the path of a panel and the rows of its listing that are in view
+func (m *fileBrowserModel) renderPanelBody(side PanelSide) string {
+	title := "Local"
+	if side == RightPanel {
+		title = "Remote"
+	}
+	path := m.panelPath(side)
+	if view := m.archiveView(side); view != nil {
+		path = view.displayPath()
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("%s: %s%s", title, path, m.filter(side).label()))
+	state := m.loadState(side)
+	if state.load != nil {
+		content.WriteString(" " + m.spinner.View())
+	}
+	content.WriteString("\n")
+	// A failed listing is reported in place of the blank line under the path
+	if state.err != nil {
+		content.WriteString(ui.ErrorStyle.Render(state.err.Error()))
+	}
+	content.WriteString("\n")
+
+	files := m.panelFiles(side)
+	first := 0
+	if m.hasParentEntry(side) {
+		first = 1 // Account for ".." entry
+	}
+	cursor := m.cursor(side)
+	view := m.view(side)
+	start, end := view.window(cursor, files.len()+first)
+	for row := start; row < end; row++ {
+		if row < first {
+			content.WriteString(m.renderParentRow(side, cursor == row) + "\n")
+		} else {
+			content.WriteString(m.renderFileRow(side, files.at(row-first), row-first, cursor == row) + "\n")
+		}
+	}
+
+	return lipgloss.NewStyle().
+		Width(view.width).Height(view.height).
+		MaxWidth(view.width).MaxHeight(view.height).
+		Render(content.String())
+}
+
+// renderParentRow renders the ".." entry of a panel
+func (m *fileBrowserModel) renderParentRow(side PanelSide, atCursor bool) string {
+	cursorIcon := " "
+	if atCursor {
+		cursorIcon = ">"
+	}
+	style := ui.RegularRowStyle
+	if atCursor && m.focusedPanel == side {
+		style = ui.SelectedRowStyle
+	}
+	return style.Render(fmt.Sprintf("%s [DIR] ..", cursorIcon))
+}
+
+// renderFileRow renders the i-th file of a panel's listing
+func (m *fileBrowserModel) renderFileRow(side PanelSide, file ssh.FileInfo, i int, atCursor bool) string {
+	cursorIcon := " "
+	if atCursor {
+		cursorIcon = ">"
+	}
+
+	selectIcon := " "
+	if m.selection(side).has(file.Name) {
+		selectIcon = "✓"
+	}
+
+	fileType := "FILE"
+	name := file.Name
+	if file.IsLink {
+		fileType = "LINK"
+		name = fmt.Sprintf("%s -> %s", file.Name, file.LinkTarget)
+	} else if file.IsDir {
+		fileType = "DIR"
+	}
+
+	style := ui.RegularRowStyle
+	if i%2 == 0 {
+		style = ui.DimRowStyle
+	}
+	if atCursor && m.focusedPanel == side {
+		style = ui.SelectedRowStyle
+	}
+
+	line := fmt.Sprintf("%s %s %s[%s] %s",
+		cursorIcon, selectIcon, renderColumns(m.columns, file), fileType, name)
+	return style.Render(line)
+}
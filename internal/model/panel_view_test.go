@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+)
+
+// newTestBrowser creates a browser showing n local and n remote files, without a connection
+func newTestBrowser(n int) *fileBrowserModel {
+	files := make([]ssh.FileInfo, n)
+	for i := range files {
+		files[i] = ssh.FileInfo{Name: fmt.Sprintf("file%06d.log", i), Size: int64(i)}
+	}
+	m := &fileBrowserModel{
+		localFiles:     files,
+		remoteFiles:    files,
+		localSelected:  newFileSelection(),
+		remoteSelected: newFileSelection(),
+		localPath:      "/data",
+		remotePath:     "/data",
+		columns:        defaultColumns,
+		localSort:      ssh.DefaultSortOrder,
+		remoteSort:     ssh.DefaultSortOrder,
+		basket:         &basket{},
+		notify:         ui.NewNotifier(),
+		width:          160,
+		height:         50,
+	}
+	m.resizePanels()
+	return m
+}
+
+func TestPanelViewWindow(t *testing.T) {
+	view := panelView{height: 12} // 10 rows
+	tests := []struct {
+		offset, cursor, total int
+		start, end            int
+	}{
+		{0, 0, 5, 0, 5},        // Listing shorter than the view
+		{0, 9, 100, 0, 10},     // Cursor on the last visible row
+		{0, 10, 100, 1, 11},    // Cursor just below the window
+		{50, 45, 100, 45, 55},  // Cursor above the window
+		{95, 99, 100, 90, 100}, // Offset past the end after the listing shrank
+	}
+	for _, tt := range tests {
+		view.offset = tt.offset
+		start, end := view.window(tt.cursor, tt.total)
+		if start != tt.start || end != tt.end {
+			t.Errorf("window(%d, %d) at offset %d = %d, %d, expected %d, %d",
+				tt.cursor, tt.total, tt.offset, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestRenderPanelBodyShowsOnlyVisibleRows(t *testing.T) {
+	m := newTestBrowser(100000)
+	m.moveCursor(LeftPanel, 50000)
+
+	body := m.renderPanelBody(LeftPanel)
+	if lines := strings.Count(body, "\n") + 1; lines != m.leftView.height {
+		t.Errorf("Expected %d lines, got %d", m.leftView.height, lines)
+	}
+	if !strings.Contains(body, "file049999.log") {
+		t.Error("Expected the file under the cursor to be rendered")
+	}
+	if strings.Contains(body, "file000000.log") {
+		t.Error("Expected rows above the window not to be rendered")
+	}
+}
+
+// BenchmarkCursorMove moves the cursor and redraws the browser. The time per
+// move should not grow with the size of the directory, also while a filter
+// hides dotfiles and a file is selected.
+func BenchmarkCursorMove(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		for _, filtered := range []bool{false, true} {
+			name := fmt.Sprintf("files=%d", n)
+			if filtered {
+				name += "/dotfiles-hidden+selected"
+			}
+			b.Run(name, func(b *testing.B) {
+				m := newTestBrowser(n)
+				if filtered {
+					m.localFilter.hideDotfiles = true
+					m.selection(LeftPanel).add(m.localFiles[0])
+				}
+				m.moveCursor(LeftPanel, n/2)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					delta := 1
+					if i%2 == 1 {
+						delta = -1
+					}
+					m.moveCursor(LeftPanel, delta)
+					_ = m.View()
+				}
+			})
+		}
+	}
+}
+
+func TestPanelListingCache(t *testing.T) {
+	m := newTestBrowser(4)
+	m.localFiles[1].Name = ".hidden"
+	m.localFilter.hideDotfiles = true
+
+	listing := m.panelFiles(LeftPanel)
+	if listing.len() != 3 || listing.at(1).Name != "file000002.log" {
+		t.Fatalf("Expected the dotfile to be filtered out, got %d files", listing.len())
+	}
+
+	// Changing the filter or sorting the files in place rebuilds the listing
+	m.localFilter.text = "3"
+	if listing := m.panelFiles(LeftPanel); listing.len() != 1 || listing.at(0).Name != "file000003.log" {
+		t.Errorf("Expected the text filter to apply, got %d files", listing.len())
+	}
+	m.localFilter = panelFilter{}
+	m.localSort.Descending = true
+	m.resortPanel(LeftPanel)
+	if listing := m.panelFiles(LeftPanel); listing.len() != 4 || listing.at(0).Name != "file000003.log" {
+		t.Errorf("Expected the listing to follow the new order, got %q first", listing.at(0).Name)
+	}
+}
@@ -0,0 +1,417 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// permissionsField identifies the focused part of the permissions dialog
+type permissionsField int
+
+const (
+	fieldFileMode permissionsField = iota
+	fieldOwner
+	fieldGroup
+	fieldRecursive
+	fieldDirMode
+)
+
+// permissionsModel edits the mode and ownership of one or more files
+type permissionsModel struct {
+	title      string
+	original   ssh.Permissions
+	canRecurse bool
+	fileMode   os.FileMode
+	dirMode    os.FileMode
+	recursive  bool
+	owner      textinput.Model
+	group      textinput.Model
+	focus      permissionsField
+	row, col   int
+	err        string
+	onApply    func(change ssh.PermissionChange) tea.Cmd
+}
+
+// Permissions message types
+type permissionsLoadedMsg struct {
+	side  PanelSide // Where the files were loaded from, whatever has the focus now
+	dir   string
+	perms []ssh.Permissions // Current permissions of each of files
+	files []ssh.FileInfo
+}
+
+type permissionsAppliedMsg struct {
+	change ssh.PermissionChange
+}
+
+// newPermissionsModel creates a permissions dialog initialised from the current permissions
+func newPermissionsModel(title string, original ssh.Permissions, canRecurse bool, onApply func(change ssh.PermissionChange) tea.Cmd) *permissionsModel {
+	owner := textinput.New()
+	owner.Prompt = ""
+	owner.Width = 10
+	owner.CharLimit = 10
+	group := owner
+
+	if original.UID >= 0 {
+		owner.SetValue(strconv.Itoa(original.UID))
+	}
+	if original.GID >= 0 {
+		group.SetValue(strconv.Itoa(original.GID))
+	}
+
+	return &permissionsModel{
+		title:      title,
+		original:   original,
+		canRecurse: canRecurse,
+		fileMode:   original.Mode,
+		dirMode:    original.Mode,
+		owner:      owner,
+		group:      group,
+		onApply:    onApply,
+	}
+}
+
+// Init initializes the permissions dialog
+func (m *permissionsModel) Init() tea.Cmd {
+	return nil
+}
+
+// fields returns the focusable fields in display order
+func (m *permissionsModel) fields() []permissionsField {
+	fields := []permissionsField{fieldFileMode, fieldOwner, fieldGroup}
+	if m.canRecurse {
+		fields = append(fields, fieldRecursive)
+		if m.recursive {
+			fields = append(fields, fieldDirMode)
+		}
+	}
+	return fields
+}
+
+// moveFocus moves the focus forward or backward through the fields
+func (m *permissionsModel) moveFocus(delta int) {
+	fields := m.fields()
+	current := 0
+	for i, field := range fields {
+		if field == m.focus {
+			current = i
+		}
+	}
+	m.focus = fields[(current+delta+len(fields))%len(fields)]
+
+	m.owner.Blur()
+	m.group.Blur()
+	switch m.focus {
+	case fieldOwner:
+		m.owner.Focus()
+	case fieldGroup:
+		m.group.Focus()
+	}
+}
+
+// focusedMode returns the mode edited by the focused checkbox grid
+func (m *permissionsModel) focusedMode() *os.FileMode {
+	if m.focus == fieldDirMode {
+		return &m.dirMode
+	}
+	return &m.fileMode
+}
+
+// modeBit returns the permission bit at a row (user/group/other) and column (r/w/x)
+func modeBit(row, col int) os.FileMode {
+	return os.FileMode(1) << uint(8-(row*3+col))
+}
+
+// Update handles messages for the permissions dialog
+func (m *permissionsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		return m, func() tea.Msg { return dialogCancelledMsg{} }
+	case "enter":
+		change, err := m.change()
+		if err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		return m, func() tea.Msg { return permissionsAppliedMsg{change: change} }
+	case "tab":
+		m.moveFocus(1)
+		return m, nil
+	case "shift+tab":
+		m.moveFocus(-1)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch m.focus {
+	case fieldFileMode, fieldDirMode:
+		mode := m.focusedMode()
+		switch key := keyMsg.String(); key {
+		case "up", "k":
+			m.row = max(0, m.row-1)
+		case "down", "j":
+			m.row = min(2, m.row+1)
+		case "left", "h":
+			m.col = max(0, m.col-1)
+		case "right", "l":
+			m.col = min(2, m.col+1)
+		case " ", "x":
+			*mode ^= modeBit(m.row, m.col)
+		case "0", "1", "2", "3", "4", "5", "6", "7":
+			// Set the whole row from an octal digit
+			digit := os.FileMode(key[0] - '0')
+			shift := uint(6 - m.row*3)
+			*mode = *mode&^(7<<shift) | digit<<shift
+		}
+	case fieldOwner:
+		m.owner, cmd = m.owner.Update(msg)
+	case fieldGroup:
+		m.group, cmd = m.group.Update(msg)
+	case fieldRecursive:
+		if keyMsg.String() == " " || keyMsg.String() == "x" {
+			m.recursive = !m.recursive
+			if m.recursive {
+				// Directories keep the edited mode, files default to it without execute bits
+				m.dirMode = m.fileMode
+				m.fileMode &^= 0111
+			} else {
+				m.fileMode = m.dirMode
+			}
+		}
+	}
+
+	m.err = ""
+	return m, cmd
+}
+
+// change builds the permission change described by the dialog
+func (m *permissionsModel) change() (ssh.PermissionChange, error) {
+	uid, err := parseID(m.owner.Value(), m.original.UID)
+	if err != nil {
+		return ssh.PermissionChange{}, fmt.Errorf("invalid owner: %w", err)
+	}
+	gid, err := parseID(m.group.Value(), m.original.GID)
+	if err != nil {
+		return ssh.PermissionChange{}, fmt.Errorf("invalid group: %w", err)
+	}
+
+	change := ssh.PermissionChange{
+		FileMode:  m.fileMode,
+		DirMode:   m.fileMode,
+		UID:       uid,
+		GID:       gid,
+		Recursive: m.recursive,
+	}
+	if m.recursive {
+		change.DirMode = m.dirMode
+	}
+	return change, nil
+}
+
+// mergePermissions combines the permissions of several files into what the
+// dialog starts from: the mode of the first, and the owner and group when all
+// share them or -1 otherwise. mixedMode reports whether the modes differ.
+func mergePermissions(perms []ssh.Permissions) (merged ssh.Permissions, mixedMode bool) {
+	if len(perms) == 0 {
+		return ssh.Permissions{UID: -1, GID: -1}, false
+	}
+	merged = perms[0]
+	for _, p := range perms[1:] {
+		if p.Mode != merged.Mode {
+			mixedMode = true
+		}
+		if p.UID != merged.UID {
+			merged.UID = -1
+		}
+		if p.GID != merged.GID {
+			merged.GID = -1
+		}
+	}
+	return merged, mixedMode
+}
+
+// parseID parses a uid/gid field, returning -1 when it is empty or the id
+// every file already has. original is -1 when the files have different ids,
+// so whatever was entered is applied to all of them.
+func parseID(value string, original int) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return -1, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return -1, fmt.Errorf("%q is not a numeric id", value)
+	}
+	if id == original {
+		return -1, nil
+	}
+	return id, nil
+}
+
+// View renders the permissions dialog
+func (m *permissionsModel) View() string {
+	var s strings.Builder
+	s.WriteString(ui.HeaderStyle.Render(m.title) + "\n\n")
+
+	fileLabel := "Mode"
+	if m.recursive {
+		fileLabel = "File mode"
+	}
+	s.WriteString(m.modeGridView(fileLabel, m.fileMode, m.focus == fieldFileMode))
+
+	s.WriteString("\n")
+	s.WriteString(m.fieldLabel("Owner (uid): ", fieldOwner) + m.owner.View() + "\n")
+	s.WriteString(m.fieldLabel("Group (gid): ", fieldGroup) + m.group.View() + "\n")
+
+	if m.canRecurse {
+		check := "[ ]"
+		if m.recursive {
+			check = "[x]"
+		}
+		s.WriteString(m.fieldLabel(check+" Apply recursively", fieldRecursive) + "\n")
+		if m.recursive {
+			s.WriteString("\n")
+			s.WriteString(m.modeGridView("Directory mode", m.dirMode, m.focus == fieldDirMode))
+		}
+	}
+
+	if m.err != "" {
+		s.WriteString("\n" + ui.ErrorStyle.Render(m.err) + "\n")
+	}
+
+	s.WriteString(ui.HelpStyle.Render("tab: next field • arrows: move • space: toggle • 0-7: set row • enter: apply • esc: cancel"))
+
+	return ui.DialogStyle.Render(s.String())
+}
+
+// fieldLabel renders a label, highlighted when its field has focus
+func (m *permissionsModel) fieldLabel(label string, field permissionsField) string {
+	if m.focus == field {
+		return ui.SelectedRowStyle.Render(label)
+	}
+	return ui.RegularRowStyle.Render(label)
+}
+
+// modeGridView renders a mode as rwx checkboxes with its octal value
+func (m *permissionsModel) modeGridView(label string, mode os.FileMode, focused bool) string {
+	var s strings.Builder
+	header := fmt.Sprintf("%s: %04o (%s)", label, mode.Perm(), mode.Perm().String())
+	if focused {
+		s.WriteString(ui.SelectedRowStyle.Render(header) + "\n")
+	} else {
+		s.WriteString(ui.RegularRowStyle.Render(header) + "\n")
+	}
+
+	s.WriteString(ui.DimRowStyle.Render("          r   w   x") + "\n")
+	for row, class := range []string{"owner", "group", "other"} {
+		line := fmt.Sprintf("  %-6s", class)
+		for col := 0; col < 3; col++ {
+			cell := "[ ]"
+			if mode&modeBit(row, col) != 0 {
+				cell = "[x]"
+			}
+			if focused && row == m.row && col == m.col {
+				cell = ui.SelectedRowStyle.Render(cell)
+			}
+			line += " " + cell
+		}
+		s.WriteString(line + "\n")
+	}
+	return s.String()
+}
+
+// handlePermissions loads the current permissions of the targeted files and opens the dialog
+func (m *fileBrowserModel) handlePermissions() (tea.Model, tea.Cmd) {
+	files := m.targetFiles()
+	if len(files) == 0 {
+		return m, nil
+	}
+	return m, loadPermissionsCmd(m.sshClient, m.focusedPanel, m.panelPath(m.focusedPanel), files)
+}
+
+// openPermissionsDialog shows the permissions dialog once the current permissions are known
+func (m *fileBrowserModel) openPermissionsDialog(msg permissionsLoadedMsg) (tea.Model, tea.Cmd) {
+	side, dir, files := msg.side, msg.dir, msg.files
+
+	canRecurse := false
+	for _, file := range files {
+		if file.IsDir {
+			canRecurse = true
+		}
+	}
+
+	perms, mixedMode := mergePermissions(msg.perms)
+	title := fmt.Sprintf("Permissions of %s", files[0].Name)
+	if len(files) > 1 {
+		title = fmt.Sprintf("Permissions of %d items", len(files))
+	}
+	if mixedMode {
+		title += " (mixed modes)"
+	}
+
+	m.permissions = newPermissionsModel(title, perms, canRecurse, func(change ssh.PermissionChange) tea.Cmd {
+		return applyPermissionsCmd(m.sshClient, side, dir, files, change)
+	})
+	// Left empty, different owners or groups are kept as they are
+	if len(msg.perms) > 1 && perms.UID < 0 {
+		m.permissions.owner.Placeholder = "mixed"
+	}
+	if len(msg.perms) > 1 && perms.GID < 0 {
+		m.permissions.group.Placeholder = "mixed"
+	}
+	m.mode = modePermissions
+	return m, m.permissions.Init()
+}
+
+// loadPermissionsCmd creates a command to read the permissions of the targeted files
+func loadPermissionsCmd(client *ssh.Client, side PanelSide, dir string, files []ssh.FileInfo) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		perms := make([]ssh.Permissions, len(files))
+		for i, file := range files {
+			var err error
+			if side == LeftPanel {
+				perms[i], err = ssh.GetLocalPermissions(filepath.Join(dir, file.Name))
+			} else {
+				perms[i], err = client.GetPermissions(remotePathJoin(dir, file.Name))
+			}
+			if err != nil {
+				return errMsg{err}
+			}
+		}
+		return permissionsLoadedMsg{side: side, dir: dir, perms: perms, files: files}
+	})
+}
+
+// applyPermissionsCmd creates a command to apply a permission change to files on one side
+func applyPermissionsCmd(client *ssh.Client, side PanelSide, dir string, files []ssh.FileInfo, change ssh.PermissionChange) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		for _, file := range files {
+			var err error
+			if side == LeftPanel {
+				err = ssh.ApplyLocalPermissions(filepath.Join(dir, file.Name), change)
+			} else {
+				err = client.ApplyPermissions(remotePathJoin(dir, file.Name), change)
+			}
+
+			if err != nil {
+				return errMsg{err}
+			}
+		}
+
+		return fileOpDoneMsg{}
+	})
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sshlepp/internal/ssh"
+)
+
+func TestPermissionsDialogUsesLoadedPanel(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	// The focus moves to the remote panel before the permissions arrive
+	m := newTestBrowser(0)
+	m.focusedPanel = RightPanel
+	files := []ssh.FileInfo{{Name: "file"}}
+	m.openPermissionsDialog(permissionsLoadedMsg{side: LeftPanel, dir: dir, files: files})
+	if m.mode != modePermissions {
+		t.Fatal("Expected the permissions dialog to open")
+	}
+
+	change := ssh.PermissionChange{FileMode: 0600, UID: -1, GID: -1}
+	if msg := m.permissions.onApply(change)(); msg != (fileOpDoneMsg{}) {
+		t.Fatalf("Expected the change to be applied, got %+v", msg)
+	}
+	info, err := os.Stat(filepath.Join(dir, "file"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the local file to be changed, got %v, %v", info, err)
+	}
+}
+
+func TestPermissionsOfMixedFiles(t *testing.T) {
+	m := newTestBrowser(0)
+	files := []ssh.FileInfo{{Name: "a"}, {Name: "b"}}
+	perms := []ssh.Permissions{{Mode: 0644, UID: 1000, GID: 100}, {Mode: 0600, UID: 0, GID: 100}}
+	m.openPermissionsDialog(permissionsLoadedMsg{side: RightPanel, dir: "/data", perms: perms, files: files})
+
+	if !strings.Contains(m.permissions.title, "mixed") {
+		t.Errorf("Expected the title to mention the mixed modes, got %q", m.permissions.title)
+	}
+	if m.permissions.owner.Value() != "" || m.permissions.group.Value() != "100" {
+		t.Errorf("Expected only the shared group to be filled in, got %q and %q", m.permissions.owner.Value(), m.permissions.group.Value())
+	}
+
+	// An owner entered for files with different owners is applied to all of them
+	m.permissions.owner.SetValue("1000")
+	change, err := m.permissions.change()
+	if err != nil || change.UID != 1000 || change.GID != -1 {
+		t.Errorf("Expected uid 1000 and an unchanged group, got %+v (%v)", change, err)
+	}
+	m.permissions.owner.SetValue("")
+	if change, _ := m.permissions.change(); change.UID != -1 {
+		t.Errorf("Expected an empty owner to keep the owners, got %d", change.UID)
+	}
+}
@@ -0,0 +1,245 @@
+package model
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// previewBytes is how much of a file the preview reads
+	previewBytes = 64 * 1024
+	// hexDumpBytes is how much of a binary file is shown as a hex dump
+	hexDumpBytes = 16 * 1024
+)
+
+// previewModel shows the beginning of a file, either in place of the
+// unfocused panel or as a full-screen pager
+type previewModel struct {
+	viewport viewport.Model
+	key      string // Identifies the previewed file so stale loads are dropped
+	name     string
+	info     string
+	loading  bool
+	err      error
+}
+
+type previewLoadedMsg struct {
+	key       string
+	name      string
+	data      []byte
+	truncated bool
+	err       error
+}
+
+// newPreviewModel creates an empty preview
+func newPreviewModel(width, height int) *previewModel {
+	return &previewModel{
+		viewport: viewport.New(width, height),
+	}
+}
+
+// setSize resizes the preview viewport
+func (m *previewModel) setSize(width, height int) {
+	m.viewport.Width = width
+	m.viewport.Height = height
+}
+
+// setLoaded shows a loaded file
+func (m *previewModel) setLoaded(msg previewLoadedMsg) {
+	m.loading = false
+	m.err = msg.err
+	if msg.err != nil {
+		m.viewport.SetContent("")
+		return
+	}
+
+	content, info := renderPreview(msg.name, msg.data, msg.truncated)
+	m.info = info
+	m.viewport.SetContent(content)
+	m.viewport.GotoTop()
+}
+
+// isBinary guesses whether data is binary by looking for NUL bytes and invalid UTF-8
+func isBinary(data []byte) bool {
+	sample := data[:min(len(data), 8192)]
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	// A multi-byte rune may have been cut off at the end of the sample
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	return !utf8.Valid(sample)
+}
+
+// renderPreview renders file data as highlighted text or a hex dump, along
+// with a short description for the footer
+func renderPreview(name string, data []byte, truncated bool) (string, string) {
+	if len(data) == 0 {
+		return ui.DimRowStyle.Render("(empty file)"), "empty"
+	}
+
+	if isBinary(data) {
+		dump := hex.Dump(data[:min(len(data), hexDumpBytes)])
+		info := "binary"
+		if truncated || len(data) > hexDumpBytes {
+			info = fmt.Sprintf("binary, first %d KB", hexDumpBytes/1024)
+		}
+		return strings.TrimSuffix(dump, "\n"), info
+	}
+
+	info := "text"
+	if truncated {
+		info = fmt.Sprintf("text, first %d KB", previewBytes/1024)
+	}
+	return ui.Highlight(string(data), name), info
+}
+
+// View renders the preview contents
+func (m *previewModel) View() string {
+	switch {
+	case m.key == "":
+		return ui.DimRowStyle.Render("Nothing to preview")
+	case m.loading:
+		return ui.DimRowStyle.Render("Loading preview...")
+	case m.err != nil:
+		return ui.ErrorStyle.Render(fmt.Sprintf("Error: %s", m.err.Error()))
+	}
+	return m.viewport.View()
+}
+
+// headerView renders the preview title line
+func (m *previewModel) headerView(width int) string {
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252")).
+		Bold(true).
+		Padding(0, 1)
+
+	title := titleStyle.Render(fmt.Sprintf("Preview: %s", m.name))
+	line := strings.Repeat("─", max(0, width-lipgloss.Width(title)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
+}
+
+// footerView renders the scroll position and file description
+func (m *previewModel) footerView(width int) string {
+	infoStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Padding(0, 1)
+
+	info := infoStyle.Render(fmt.Sprintf("%s • %3.f%%", m.info, m.viewport.ScrollPercent()*100))
+	line := strings.Repeat("─", max(0, width-lipgloss.Width(info)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
+}
+
+// previewTarget returns the file under the cursor of the focused panel
+func (m *fileBrowserModel) previewTarget() (key, name, fullPath string, ok bool) {
+	file, ok := m.cursorFile(m.focusedPanel)
+	if !ok || file.IsDir || m.archiveView(m.focusedPanel) != nil {
+		return "", "", "", false
+	}
+
+	if m.focusedPanel == LeftPanel {
+		fullPath = filepath.Join(m.localPath, file.Name)
+	} else {
+		fullPath = remotePathJoin(m.remotePath, file.Name)
+	}
+	return fmt.Sprintf("%d:%s", m.focusedPanel, fullPath), file.Name, fullPath, true
+}
+
+// refreshPreviewCmd loads the file under the cursor when the preview is
+// visible and showing something else
+func (m *fileBrowserModel) refreshPreviewCmd() tea.Cmd {
+	if !m.showPreview && m.mode != modePager {
+		return nil
+	}
+
+	key, name, fullPath, ok := m.previewTarget()
+	if !ok {
+		m.preview.key = ""
+		m.preview.name = ""
+		return nil
+	}
+	if key == m.preview.key {
+		return nil
+	}
+
+	m.preview.key = key
+	m.preview.name = name
+	m.preview.loading = true
+	return loadPreviewCmd(m.sshClient, m.focusedPanel, fullPath, key, name)
+}
+
+// handleTogglePreview shows or hides the preview in place of the unfocused panel
+func (m *fileBrowserModel) handleTogglePreview() (tea.Model, tea.Cmd) {
+	m.showPreview = !m.showPreview
+	return m, m.refreshPreviewCmd()
+}
+
+// handleOpenPager shows the preview of the file under the cursor full screen
+func (m *fileBrowserModel) handleOpenPager() (tea.Model, tea.Cmd) {
+	if _, _, _, ok := m.previewTarget(); !ok {
+		return m, nil
+	}
+
+	m.mode = modePager
+	m.preview.setSize(m.width-2, m.height-4)
+	return m, m.refreshPreviewCmd()
+}
+
+// updatePager handles keys while the full-screen pager is open
+func (m *fileBrowserModel) updatePager(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "V":
+		m.mode = modeBrowse
+		m.preview.setSize(m.leftView.width, m.leftView.height)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.preview.viewport, cmd = m.preview.viewport.Update(msg)
+	return m, cmd
+}
+
+// renderPreviewPanel renders the preview in a panel frame of the given width
+func (m *fileBrowserModel) renderPreviewPanel(width int) string {
+	header := m.preview.headerView(m.preview.viewport.Width)
+	footer := m.preview.footerView(m.preview.viewport.Width)
+	content := fmt.Sprintf("%s\n%s\n%s", header, m.preview.View(), footer)
+
+	return ui.UnfocusedPanelStyle.Width(width).Height(m.height - 4).Render(content)
+}
+
+// pagerView renders the full-screen pager
+func (m *fileBrowserModel) pagerView() string {
+	header := m.preview.headerView(m.preview.viewport.Width)
+	footer := m.preview.footerView(m.preview.viewport.Width)
+	help := ui.HelpStyle.Render("↑/↓/PgUp/PgDn: scroll • esc/q: close")
+	return lipgloss.JoinVertical(lipgloss.Left, header, m.preview.View(), footer, help)
+}
+
+// loadPreviewCmd creates a command to read the beginning of a file for the preview
+func loadPreviewCmd(client *ssh.Client, side PanelSide, fullPath, key, name string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		var data []byte
+		var truncated bool
+		var err error
+		if side == LeftPanel {
+			data, truncated, err = ssh.ReadLocalFileHead(fullPath, previewBytes)
+		} else {
+			data, truncated, err = client.ReadFileHead(fullPath, previewBytes)
+		}
+
+		return previewLoadedMsg{key: key, name: name, data: data, truncated: truncated, err: err}
+	})
+}
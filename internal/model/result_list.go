@@ -0,0 +1,243 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sshlepp/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resultBatchSize is the most results delivered to the UI in one message
+const resultBatchSize = 500
+
+// resultList is a scrolling list of search results that fills in while the
+// search runs in the background. The find and grep views are built on it.
+type resultList[T any] struct {
+	side    PanelSide
+	root    string
+	query   string
+	results []T
+	cursor  int
+	offset  int // First visible result
+	width   int
+	height  int
+	running bool
+	cancel  context.CancelFunc
+	found   chan T
+	err     error // Set by the search before found is closed
+}
+
+// resultsMsg delivers a batch of results to the list that asked for them
+type resultsMsg[T any] struct {
+	list    *resultList[T]
+	results []T
+	done    bool
+}
+
+// newResultList creates the list for a search of root on one side
+func newResultList[T any](side PanelSide, root, query string, width, height int) *resultList[T] {
+	return &resultList[T]{
+		side:    side,
+		root:    root,
+		query:   query,
+		width:   width,
+		height:  height,
+		running: true,
+		found:   make(chan T, resultBatchSize),
+	}
+}
+
+// setSize resizes the list
+func (l *resultList[T]) setSize(width, height int) {
+	l.width = width
+	l.height = height
+	l.scrollToCursor()
+}
+
+// rows returns how many results fit on screen
+func (l *resultList[T]) rows() int {
+	return max(1, l.height-4)
+}
+
+// scrollToCursor keeps the cursor within the visible results
+func (l *resultList[T]) scrollToCursor() {
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	} else if l.cursor >= l.offset+l.rows() {
+		l.offset = l.cursor - l.rows() + 1
+	}
+}
+
+// moveCursor moves the cursor by delta results
+func (l *resultList[T]) moveCursor(delta int) {
+	l.cursor = max(0, min(len(l.results)-1, l.cursor+delta))
+	l.scrollToCursor()
+}
+
+// stop cancels the search if it is still running
+func (l *resultList[T]) stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
+// relativePath returns a path below the search root relative to it
+func (l *resultList[T]) relativePath(fullPath string) string {
+	separator := "/"
+	if l.side == LeftPanel {
+		separator = string(filepath.Separator)
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(fullPath, l.root), separator)
+}
+
+// receive adds a batch of results, waiting for the next one unless the search is done
+func (l *resultList[T]) receive(msg resultsMsg[T]) tea.Cmd {
+	l.results = append(l.results, msg.results...)
+	if msg.done {
+		l.running = false
+		return nil
+	}
+	return l.waitCmd()
+}
+
+// handleKey handles the keys every result list shares, reporting whether it used the key
+func (l *resultList[T]) handleKey(key string) (tea.Cmd, bool) {
+	switch key {
+	case "esc":
+		if l.running {
+			// First esc stops the search, keeping what was found so far
+			l.stop()
+			return nil, true
+		}
+		return func() tea.Msg { return dialogCancelledMsg{} }, true
+	case "q":
+		l.stop()
+		return func() tea.Msg { return dialogCancelledMsg{} }, true
+	case "up", "k":
+		l.moveCursor(-1)
+	case "down", "j":
+		l.moveCursor(1)
+	case "pgup":
+		l.moveCursor(-l.rows())
+	case "pgdown":
+		l.moveCursor(l.rows())
+	case "home", "g":
+		l.moveCursor(-len(l.results))
+	case "end", "G":
+		l.moveCursor(len(l.results))
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// view renders the list between a title and a footer counting the results.
+// extra is appended to the footer's status and renderRow draws result i.
+func (l *resultList[T]) view(title, noun, extra, help string, renderRow func(i int) string) string {
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252")).
+		Bold(true).
+		Padding(0, 1)
+	title = titleStyle.Render(fmt.Sprintf("%s: %s in %s", title, l.query, l.root))
+	header := lipgloss.JoinHorizontal(lipgloss.Center, title,
+		strings.Repeat("─", max(0, l.width-lipgloss.Width(title))))
+
+	var rows []string
+	if len(l.results) == 0 {
+		message := "No matches"
+		if l.running {
+			message = "Searching..."
+		}
+		rows = append(rows, ui.DimRowStyle.Render(message))
+	}
+	for i := l.offset; i < len(l.results) && i < l.offset+l.rows(); i++ {
+		rows = append(rows, renderRow(i))
+	}
+	for len(rows) < l.rows() {
+		rows = append(rows, "")
+	}
+
+	status := fmt.Sprintf("%d %s", len(l.results), noun)
+	switch {
+	case l.running:
+		status = fmt.Sprintf("searching... %s", status)
+	case l.err != nil:
+		status = fmt.Sprintf("%s • error: %s", status, l.err.Error())
+	}
+	if extra != "" {
+		status = fmt.Sprintf("%s • %s", status, extra)
+	}
+	infoStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Padding(0, 1)
+	info := infoStyle.Render(status)
+	footer := lipgloss.JoinHorizontal(lipgloss.Center,
+		strings.Repeat("─", max(0, l.width-lipgloss.Width(info))), info)
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, strings.Join(rows, "\n"), footer, ui.HelpStyle.Render(help))
+}
+
+// rowStyle returns the style of row i, striped and highlighted under the cursor
+func (l *resultList[T]) rowStyle(i int) lipgloss.Style {
+	switch {
+	case i == l.cursor:
+		return ui.SelectedRowStyle
+	case i%2 == 0:
+		return ui.DimRowStyle
+	}
+	return ui.RegularRowStyle
+}
+
+// runCmd creates a command that runs search, feeding what it finds to the list
+func (l *resultList[T]) runCmd(ctx context.Context, search func(ctx context.Context, found func(T)) error) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		found := func(result T) {
+			select {
+			case l.found <- result:
+			case <-ctx.Done():
+			}
+		}
+
+		if err := search(ctx, found); err != nil && ctx.Err() == nil {
+			l.err = err
+		}
+		close(l.found)
+		return nil
+	})
+}
+
+// start runs search in the background and waits for its first results
+func (l *resultList[T]) start(search func(ctx context.Context, found func(T)) error) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	return tea.Batch(l.runCmd(ctx, search), l.waitCmd())
+}
+
+// waitCmd waits for the next batch of results
+func (l *resultList[T]) waitCmd() tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-l.found
+		if !ok {
+			return resultsMsg[T]{list: l, done: true}
+		}
+
+		batch := []T{result}
+		for len(batch) < resultBatchSize {
+			select {
+			case result, ok := <-l.found:
+				if !ok {
+					return resultsMsg[T]{list: l, results: batch, done: true}
+				}
+				batch = append(batch, result)
+			default:
+				return resultsMsg[T]{list: l, results: batch}
+			}
+		}
+		return resultsMsg[T]{list: l, results: batch}
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// drainResults delivers batches to a list until its search is done
+func drainResults[T any](t *testing.T, list *resultList[T], cmd tea.Cmd) {
+	t.Helper()
+	for cmd != nil {
+		msg, ok := cmd().(resultsMsg[T])
+		if !ok || msg.list != list {
+			t.Fatalf("Expected results for the list, got %#v", msg)
+		}
+		cmd = list.receive(msg)
+	}
+}
+
+func TestResultListCollectsBatches(t *testing.T) {
+	list := newResultList[int](LeftPanel, "/data", "*.log", 80, 14)
+	search := func(ctx context.Context, found func(int)) error {
+		for i := range 1200 {
+			found(i)
+		}
+		return errors.New("permission denied")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go list.runCmd(ctx, search)()
+	drainResults(t, list, list.waitCmd())
+
+	if len(list.results) != 1200 || list.results[1199] != 1199 || list.running || list.err == nil {
+		t.Fatalf("Unexpected list state: %d results, running %v, error %v", len(list.results), list.running, list.err)
+	}
+	if view := list.view("Find", "found", "", "", func(int) string { return "row" }); !strings.Contains(view, "1200 found • error: permission denied") {
+		t.Errorf("Expected the footer to count the results and show the error:\n%s", view)
+	}
+}
+
+func TestResultListKeys(t *testing.T) {
+	list := newResultList[int](LeftPanel, "/data", "x", 80, 14) // 10 rows
+	list.results = make([]int, 25)
+
+	for _, key := range []string{"pgdown", "down", "j"} {
+		if _, ok := list.handleKey(key); !ok {
+			t.Fatalf("Expected %q to be handled", key)
+		}
+	}
+	if list.cursor != 12 || list.offset != 3 {
+		t.Errorf("Expected cursor 12 at offset 3, got %d at %d", list.cursor, list.offset)
+	}
+	list.handleKey("end")
+	if list.cursor != 24 || list.offset != 15 {
+		t.Errorf("Expected cursor 24 at offset 15, got %d at %d", list.cursor, list.offset)
+	}
+	if _, ok := list.handleKey("enter"); ok {
+		t.Error("Expected enter to be left to the view")
+	}
+
+	// The first esc only stops a running search
+	stopped := false
+	list.cancel = func() { stopped = true }
+	if cmd, _ := list.handleKey("esc"); cmd != nil || !stopped {
+		t.Errorf("Expected esc to stop the search without closing, got %v", cmd)
+	}
+	list.running = false
+	if cmd, _ := list.handleKey("esc"); cmd == nil {
+		t.Error("Expected esc to close a finished search")
+	} else if _, ok := cmd().(dialogCancelledMsg); !ok {
+		t.Error("Expected esc to cancel the dialog")
+	}
+}
+
+func TestStaleResultsIgnored(t *testing.T) {
+	m := newTestBrowser(1)
+	m.find = newFindModel(LeftPanel, "/data", "x", 80, 14)
+	old := newResultList[ssh.FindResult](LeftPanel, "/data", "x", 80, 14)
+
+	m.Update(resultsMsg[ssh.FindResult]{list: old, results: []ssh.FindResult{{Path: "/data/a"}}, done: true})
+	if len(m.find.results) != 0 || !m.find.running {
+		t.Error("Results of a replaced search should be ignored")
+	}
+	m.Update(resultsMsg[ssh.FindResult]{list: m.find.resultList, results: []ssh.FindResult{{Path: "/data/a"}}, done: true})
+	if len(m.find.results) != 1 || m.find.running {
+		t.Error("Results of the current search should be added")
+	}
+}
@@ -0,0 +1,203 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"strings"
+
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fileSelection is the set of selected files of a panel. It keeps their count
+// and total size up to date as files are selected, so the panel footer
+// doesn't have to add them up on every redraw.
+type fileSelection struct {
+	sizes map[string]int64 // Size counted for each selected name, 0 for directories
+	size  int64
+}
+
+// newFileSelection creates an empty selection
+func newFileSelection() *fileSelection {
+	return &fileSelection{sizes: make(map[string]int64)}
+}
+
+// has reports whether a file is selected
+func (s *fileSelection) has(name string) bool {
+	_, ok := s.sizes[name]
+	return ok
+}
+
+// len returns how many files are selected
+func (s *fileSelection) len() int {
+	return len(s.sizes)
+}
+
+// add selects a file, or updates the size counted for it when it already is
+func (s *fileSelection) add(file ssh.FileInfo) {
+	s.remove(file.Name)
+	var size int64
+	if !file.IsDir {
+		size = file.Size
+	}
+	s.sizes[file.Name] = size
+	s.size += size
+}
+
+// remove deselects a file
+func (s *fileSelection) remove(name string) {
+	if size, ok := s.sizes[name]; ok {
+		delete(s.sizes, name)
+		s.size -= size
+	}
+}
+
+// toggle selects a file that isn't selected and deselects one that is
+func (s *fileSelection) toggle(file ssh.FileInfo) {
+	if s.has(file.Name) {
+		s.remove(file.Name)
+	} else {
+		s.add(file)
+	}
+}
+
+// clear deselects everything
+func (s *fileSelection) clear() {
+	clear(s.sizes)
+	s.size = 0
+}
+
+// clone returns a copy of the selection
+func (s *fileSelection) clone() *fileSelection {
+	return &fileSelection{sizes: maps.Clone(s.sizes), size: s.size}
+}
+
+// rangeSelection is a range of files being selected by moving the cursor with shift held
+type rangeSelection struct {
+	side   PanelSide
+	anchor int            // Cursor position the range started at
+	base   *fileSelection // Selection from before the range started
+}
+
+// rangeKeys extend the range selection instead of ending it
+var rangeKeys = map[string]bool{
+	"shift+up": true, "shift+down": true, "K": true, "J": true,
+}
+
+// resetSelection clears a panel's selection, range selection and filter text,
+// which only make sense for the listing they were made in
+func (m *fileBrowserModel) resetSelection(side PanelSide) {
+	m.filter(side).text = ""
+	m.selection(side).clear()
+	if m.selectRange != nil && m.selectRange.side == side {
+		m.selectRange = nil
+	}
+}
+
+// moveCursor moves a panel's cursor by delta entries, staying within the listing
+func (m *fileBrowserModel) moveCursor(side PanelSide, delta int) {
+	if side == LeftPanel {
+		m.localCursor = max(0, min(m.maxCursor(side), m.localCursor+delta))
+	} else {
+		m.remoteCursor = max(0, min(m.maxCursor(side), m.remoteCursor+delta))
+	}
+	m.ensureCursorVisible(side)
+}
+
+// handleRangeSelect moves the focused panel's cursor and selects every file
+// between it and where the range started, on top of the earlier selection
+func (m *fileBrowserModel) handleRangeSelect(delta int) (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	selected := m.selection(side)
+	if m.selectRange == nil || m.selectRange.side != side {
+		m.selectRange = &rangeSelection{side: side, anchor: m.cursor(side), base: selected.clone()}
+	}
+	m.moveCursor(side, delta)
+
+	*selected = *m.selectRange.base.clone()
+	files := m.panelFiles(side)
+	first := 0
+	if m.hasParentEntry(side) {
+		first = 1 // Account for ".." entry
+	}
+	from, to := min(m.selectRange.anchor, m.cursor(side)), max(m.selectRange.anchor, m.cursor(side))
+	for i := max(from, first); i <= to && i-first < files.len(); i++ {
+		selected.add(files.at(i - first))
+	}
+
+	return m, nil
+}
+
+// cursor returns the cursor position of a panel, counting the ".." entry
+func (m *fileBrowserModel) cursor(side PanelSide) int {
+	if side == LeftPanel {
+		return m.localCursor
+	}
+	return m.remoteCursor
+}
+
+// handleSelectAll selects every file listed in the focused panel
+func (m *fileBrowserModel) handleSelectAll() (tea.Model, tea.Cmd) {
+	selected, files := m.selection(m.focusedPanel), m.panelFiles(m.focusedPanel)
+	for i := range files.len() {
+		selected.add(files.at(i))
+	}
+	return m, nil
+}
+
+// handleInvertSelection selects the listed files of the focused panel that
+// aren't selected and deselects those that are
+func (m *fileBrowserModel) handleInvertSelection() (tea.Model, tea.Cmd) {
+	selected, files := m.selection(m.focusedPanel), m.panelFiles(m.focusedPanel)
+	for i := range files.len() {
+		selected.toggle(files.at(i))
+	}
+	return m, nil
+}
+
+// handleClearSelection deselects all files of the focused panel, including
+// any its filter hides
+func (m *fileBrowserModel) handleClearSelection() (tea.Model, tea.Cmd) {
+	m.selection(m.focusedPanel).clear()
+	return m, nil
+}
+
+// handleGlobSelect prompts for a pattern such as *.log and selects the listed
+// files of the focused panel whose names match it
+func (m *fileBrowserModel) handleGlobSelect() (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	m.prompt = newPromptModel("Select files matching:", "", func(value string) tea.Cmd {
+		pattern := strings.TrimSpace(value)
+		selected, files := m.selection(side), m.panelFiles(side)
+		for i := range files.len() {
+			// The pattern was checked by validate, so matching can't fail
+			if ok, _ := path.Match(pattern, files.at(i).Name); ok {
+				selected.add(files.at(i))
+			}
+		}
+		return nil
+	})
+	m.prompt.validate = func(value string) error {
+		pattern := strings.TrimSpace(value)
+		if pattern == "" {
+			return fmt.Errorf("pattern cannot be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		return nil
+	}
+	m.mode = modePrompt
+	return m, m.prompt.Init()
+}
+
+// selectionSummary describes a panel's selection for its footer, "" when nothing is selected
+func (m *fileBrowserModel) selectionSummary(side PanelSide) string {
+	selected := m.selection(side)
+	if selected.len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d selected, %s", selected.len(), formatSize(selected.size))
+}
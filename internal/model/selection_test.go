@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"sshlepp/internal/ssh"
+)
+
+func TestFileSelection(t *testing.T) {
+	s := newFileSelection()
+	s.add(ssh.FileInfo{Name: "a", Size: 100})
+	s.add(ssh.FileInfo{Name: "dir", Size: 4096, IsDir: true})
+	s.toggle(ssh.FileInfo{Name: "b", Size: 20})
+	if s.len() != 3 || s.size != 120 {
+		t.Fatalf("Expected 3 files of 120 bytes, got %d of %d", s.len(), s.size)
+	}
+
+	// Selecting a file again counts its new size instead of adding it twice
+	s.add(ssh.FileInfo{Name: "a", Size: 50})
+	s.toggle(ssh.FileInfo{Name: "b", Size: 20})
+	s.remove("missing")
+	if s.len() != 2 || s.size != 50 || !s.has("dir") || s.has("b") {
+		t.Errorf("Expected a and dir with 50 bytes, got %v", s.sizes)
+	}
+
+	base := s.clone()
+	s.clear()
+	if s.len() != 0 || s.size != 0 || base.len() != 2 || base.size != 50 {
+		t.Errorf("Expected clearing not to affect the clone, got %v and %v", s.sizes, base.sizes)
+	}
+}
+
+func TestSelectionSummaryAfterReload(t *testing.T) {
+	m := newTestBrowser(3)
+	m.handleSelectAll()
+	if summary := m.selectionSummary(LeftPanel); summary != "3 selected, 3B" {
+		t.Fatalf("Unexpected summary %q", summary)
+	}
+
+	// A reload drops files that are gone and counts the new sizes of the rest
+	load := &dirLoad{side: LeftPanel, path: m.localPath, files: []ssh.FileInfo{
+		{Name: "file000001.log", Size: 1000},
+		{Name: "file000002.log", Size: 2},
+	}}
+	m.localLoad.load = load
+	m.receiveFiles(panelFilesMsg{load: load, done: true})
+	if summary := m.selectionSummary(LeftPanel); summary != "2 selected, 1002B" {
+		t.Errorf("Unexpected summary after reload %q", summary)
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handleShell suspends the TUI and opens an interactive shell in the current
+// remote directory, refreshing the listings once it exits
+func (m *fileBrowserModel) handleShell() (tea.Model, tea.Cmd) {
+	return m, tea.Exec(m.sshClient.NewShell(m.remotePath), func(err error) tea.Msg {
+		if err != nil {
+			return errMsg{err}
+		}
+		return fileOpDoneMsg{}
+	})
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"fmt"
+
+	"sshlepp/internal/config"
+	"sshlepp/internal/ssh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sortOrderFromConfig returns a remembered sort order, or the default when there is none
+func sortOrderFromConfig(saved *config.Sort) ssh.SortOrder {
+	if saved == nil {
+		return ssh.DefaultSortOrder
+	}
+	key, _ := ssh.ParseSortKey(saved.By)
+	return ssh.SortOrder{Key: key, Descending: saved.Descending, DirsFirst: saved.DirsFirst}
+}
+
+// sortOrder returns how a panel orders its files
+func (m *fileBrowserModel) sortOrder(side PanelSide) *ssh.SortOrder {
+	if side == LeftPanel {
+		return &m.localSort
+	}
+	return &m.remoteSort
+}
+
+// sortedFiles returns a sorted copy of files in a panel's order, leaving files untouched
+func (m *fileBrowserModel) sortedFiles(side PanelSide, files []ssh.FileInfo) []ssh.FileInfo {
+	sorted := append([]ssh.FileInfo(nil), files...)
+	ssh.SortFiles(sorted, *m.sortOrder(side))
+	return sorted
+}
+
+// resortPanel sorts a panel's files again after its order changed, keeping the
+// cursor on the same file
+func (m *fileBrowserModel) resortPanel(side PanelSide) {
+	cursorName := ""
+	if file, ok := m.cursorFile(side); ok {
+		cursorName = file.Name
+	}
+
+	ssh.SortFiles(m.listedFiles(side), *m.sortOrder(side))
+	m.invalidateListing(side)
+
+	if cursorName != "" {
+		m.placeCursor(side, cursorName)
+	}
+}
+
+// handleSort changes the focused panel's sort order and remembers it for future sessions
+func (m *fileBrowserModel) handleSort(change func(order *ssh.SortOrder)) (tea.Model, tea.Cmd) {
+	side := m.focusedPanel
+	order := m.sortOrder(side)
+	change(order)
+	m.resortPanel(side)
+
+	if m.config != nil {
+		saved := &config.Sort{By: order.Key.String(), Descending: order.Descending, DirsFirst: order.DirsFirst}
+		if side == LeftPanel {
+			m.config.LocalSort = saved
+		} else {
+			m.config.RemoteSort = saved
+		}
+		if err := m.config.Save(); err != nil {
+			return m, m.notify.Warn(fmt.Sprintf("Sort order won't be remembered: %s", err))
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,339 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"sshlepp/internal/ssh"
+	"sshlepp/internal/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// tailBacklogBytes is how much of the end of a file is shown when following starts
+	tailBacklogBytes = 16 * 1024
+	// tailChunkBytes is the most read from a followed file in one poll
+	tailChunkBytes = 256 * 1024
+	// tailMaxLines is how many lines are kept before the oldest are dropped
+	tailMaxLines = 10000
+	// tailPollInterval is how often a followed file is checked for new data
+	tailPollInterval = time.Second
+)
+
+// tailModel follows a remote file as it grows, like tail -f
+type tailModel struct {
+	client   *ssh.Client
+	path     string
+	viewport viewport.Model
+	lines    []string
+	partial  string // Last line while it has no trailing newline yet
+	state    ssh.FollowState
+	started  bool
+	polling  bool // A poll is scheduled or in flight
+	paused   bool
+	follow   bool // Keep the newest line in view
+	pattern  *regexp.Regexp
+	search   textinput.Model
+	editing  bool // The search input has focus
+	match    int  // Line of the current search match
+	err      error
+}
+
+// Tail message types
+type tailTickMsg struct {
+	tail *tailModel
+}
+
+type tailDataMsg struct {
+	tail    *tailModel
+	data    []byte
+	state   ssh.FollowState
+	reset   bool
+	started bool // First read, which may begin in the middle of a line
+	err     error
+}
+
+// newTailModel creates a viewer following a remote file
+func newTailModel(client *ssh.Client, path string, width, height int) *tailModel {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "regular expression"
+
+	return &tailModel{
+		client:   client,
+		path:     path,
+		viewport: viewport.New(width, height),
+		follow:   true,
+		search:   search,
+		match:    -1,
+	}
+}
+
+// Init starts following the file
+func (m *tailModel) Init() tea.Cmd {
+	m.polling = true
+	return m.pollCmd()
+}
+
+// setSize resizes the tail viewport
+func (m *tailModel) setSize(width, height int) {
+	m.viewport.Width = width
+	m.viewport.Height = height
+	m.refresh()
+}
+
+// Update handles messages for the tail viewer
+func (m *tailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tailTickMsg:
+		if m.paused {
+			m.polling = false
+			return m, nil
+		}
+		return m, m.pollCmd()
+
+	case tailDataMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.started = true
+			m.state = msg.state
+			m.appendData(msg.data, msg.reset, msg.started)
+		}
+		return m, tea.Tick(tailPollInterval, func(time.Time) tea.Msg {
+			return tailTickMsg{tail: m}
+		})
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateSearch(msg)
+		}
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+// handleKey handles keys while browsing the followed file
+func (m *tailModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "t":
+		return m, func() tea.Msg { return dialogCancelledMsg{} }
+	case " ", "p":
+		m.paused = !m.paused
+		if !m.paused && !m.polling {
+			m.polling = true
+			return m, m.pollCmd()
+		}
+		return m, nil
+	case "/":
+		m.editing = true
+		m.search.SetValue("")
+		return m, m.search.Focus()
+	case "n":
+		m.jumpToMatch(1)
+		return m, nil
+	case "N":
+		m.jumpToMatch(-1)
+		return m, nil
+	case "G", "end":
+		m.follow = true
+		m.viewport.GotoBottom()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	m.follow = m.viewport.AtBottom()
+	return m, cmd
+}
+
+// updateSearch handles keys while the search input has focus
+func (m *tailModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editing = false
+		m.search.Blur()
+		return m, nil
+	case "enter":
+		value := m.search.Value()
+		if value == "" {
+			m.pattern = nil
+		} else {
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				m.err = fmt.Errorf("invalid regular expression: %w", err)
+				return m, nil
+			}
+			m.pattern = pattern
+		}
+		m.err = nil
+		m.editing = false
+		m.search.Blur()
+		// Start from the newest match
+		m.match = len(m.lines)
+		if !m.jumpToMatch(-1) {
+			m.match = -1
+			m.refresh()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	return m, cmd
+}
+
+// appendData adds newly read data to the buffered lines
+func (m *tailModel) appendData(data []byte, reset, started bool) {
+	if reset {
+		m.partial = ""
+		m.lines = append(m.lines, ui.DimRowStyle.Render("--- file truncated or rotated ---"))
+	}
+	if len(data) == 0 && !reset {
+		return
+	}
+
+	lines := strings.Split(m.partial+string(data), "\n")
+	m.partial = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+	if started && m.state.Offset > int64(len(data)) && len(lines) > 0 {
+		// The backlog starts in the middle of a line
+		lines = lines[1:]
+	}
+
+	for _, line := range lines {
+		m.lines = append(m.lines, ui.SanitizeLine(line))
+	}
+	if dropped := len(m.lines) - tailMaxLines; dropped > 0 {
+		m.lines = m.lines[dropped:]
+		m.match = max(-1, m.match-dropped)
+	}
+
+	m.refresh()
+}
+
+// refresh re-renders the buffered lines into the viewport
+func (m *tailModel) refresh() {
+	lines := m.lines
+	if m.partial != "" {
+		lines = append(lines[:len(lines):len(lines)], ui.SanitizeLine(m.partial))
+	}
+
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		if m.pattern != nil {
+			line = ui.HighlightMatches(line, m.pattern)
+		}
+		if i == m.match {
+			line = ui.SelectedRowStyle.Render(">") + " " + line
+		}
+		rendered[i] = line
+	}
+
+	m.viewport.SetContent(strings.Join(rendered, "\n"))
+	if m.follow {
+		m.viewport.GotoBottom()
+	}
+}
+
+// jumpToMatch scrolls to the next (1) or previous (-1) line matching the search
+func (m *tailModel) jumpToMatch(direction int) bool {
+	if m.pattern == nil {
+		return false
+	}
+
+	for i := m.match + direction; i >= 0 && i < len(m.lines); i += direction {
+		if m.pattern.MatchString(m.lines[i]) {
+			m.match = i
+			m.follow = false
+			m.refresh()
+			m.viewport.SetYOffset(i - m.viewport.Height/2)
+			return true
+		}
+	}
+	return false
+}
+
+// View renders the tail viewer
+func (m *tailModel) View() string {
+	state := "following"
+	switch {
+	case m.paused:
+		state = "paused"
+	case !m.follow:
+		state = "scrolled"
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252")).
+		Bold(true).
+		Padding(0, 1)
+	title := titleStyle.Render(fmt.Sprintf("Tail: %s [%s]", m.path, state))
+	header := lipgloss.JoinHorizontal(lipgloss.Center, title,
+		strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title))))
+
+	infoStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Padding(0, 1)
+	info := fmt.Sprintf("%d lines", len(m.lines))
+	if m.pattern != nil {
+		info += fmt.Sprintf(" • /%s", m.pattern)
+	}
+	infoView := infoStyle.Render(info)
+	footer := lipgloss.JoinHorizontal(lipgloss.Center,
+		strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(infoView))), infoView)
+
+	var bottom string
+	switch {
+	case m.editing:
+		bottom = "\n" + m.search.View()
+	case m.err != nil:
+		bottom = "\n" + ui.ErrorStyle.Render(fmt.Sprintf("Error: %s", m.err.Error()))
+	default:
+		bottom = ui.HelpStyle.Render("↑/↓/PgUp/PgDn: scroll • G: follow • space: pause • /: search • n/N: next/prev match • esc/q: close")
+	}
+
+	content := m.viewport.View()
+	if !m.started && m.err == nil {
+		content = ui.DimRowStyle.Render("Opening file...")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, header, content, footer, bottom)
+}
+
+// pollCmd creates a command that reads whatever was appended since the last poll
+func (m *tailModel) pollCmd() tea.Cmd {
+	client, path, state, started := m.client, m.path, m.state, m.started
+	return tea.Cmd(func() tea.Msg {
+		if !started {
+			var err error
+			state, err = client.StartFollow(path, tailBacklogBytes)
+			if err != nil {
+				return tailDataMsg{tail: m, err: err}
+			}
+		}
+
+		data, next, reset, err := client.ReadAppended(path, state, tailChunkBytes)
+		return tailDataMsg{tail: m, data: data, state: next, reset: reset, started: !started, err: err}
+	})
+}
+
+// handleTail opens the tail viewer for the remote file under the cursor
+func (m *fileBrowserModel) handleTail() (tea.Model, tea.Cmd) {
+	if m.focusedPanel != RightPanel {
+		return m, nil
+	}
+	file, ok := m.cursorFile(RightPanel)
+	if !ok || file.IsDir {
+		return m, nil
+	}
+
+	m.tail = newTailModel(m.sshClient, remotePathJoin(m.remotePath, file.Name), m.width-2, m.height-4)
+	m.mode = modeTail
+	return m, m.tail.Init()
+}
@@ -0,0 +1,277 @@
+package ssh
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarEntry describes one entry of a test archive
+type tarEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+}
+
+// buildTarGz creates a gzipped tar archive in memory
+func buildTarGz(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Mode:     0644,
+			Size:     int64(len(entry.body)),
+			Linkname: entry.linkname,
+		}
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(entry.body)); err != nil {
+			t.Fatalf("Failed to write body: %v", err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestExtractTarGz(t *testing.T) {
+	dest := t.TempDir()
+	archive := buildTarGz(t, []tarEntry{
+		{name: "project/", typeflag: tar.TypeDir},
+		{name: "project/main.go", typeflag: tar.TypeReg, body: "package main\n"},
+		{name: "project/docs/readme.md", typeflag: tar.TypeReg, body: "# docs\n"},
+		{name: "project/current", typeflag: tar.TypeSymlink, linkname: "main.go"},
+	})
+
+	if err := ExtractTarGz(archive, dest); err != nil {
+		t.Fatalf("ExtractTarGz failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dest, "project", "docs", "readme.md"))
+	if err != nil || string(content) != "# docs\n" {
+		t.Errorf("Expected nested file to be extracted, got %q (%v)", content, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "project", "current")); err != nil || target != "main.go" {
+		t.Errorf("Expected symlink to main.go, got %q (%v)", target, err)
+	}
+}
+
+func TestExtractTarGzHardLinks(t *testing.T) {
+	dest := t.TempDir()
+	archive := buildTarGz(t, []tarEntry{
+		{name: "data/report.csv", typeflag: tar.TypeReg, body: "a,b\n"},
+		{name: "backup/report.csv", typeflag: tar.TypeLink, linkname: "data/report.csv"},
+		{name: "data/queue", typeflag: tar.TypeFifo},
+	})
+
+	err := ExtractTarGz(archive, dest)
+	var skipped *SkippedEntriesError
+	if !errors.As(err, &skipped) || len(skipped.Names) != 1 || skipped.Names[0] != "data/queue" {
+		t.Fatalf("Expected the fifo to be reported as skipped, got %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(dest, "backup", "report.csv")); err != nil || string(content) != "a,b\n" {
+		t.Errorf("Expected the hard link to be extracted, got %q (%v)", content, err)
+	}
+
+	// A hard link can't reach outside the destination
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	escape := buildTarGz(t, []tarEntry{{name: "secret", typeflag: tar.TypeLink, linkname: "../" + filepath.Base(filepath.Dir(outside)) + "/secret"}})
+	if err := ExtractTarGz(escape, dest); err == nil {
+		t.Error("Expected a hard link outside the archive to be rejected")
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "secret")); err == nil {
+		t.Error("Hard link outside the archive was created")
+	}
+}
+
+func TestExtractTarGzRejectsEscapes(t *testing.T) {
+	tests := map[string]tarEntry{
+		"parent traversal": {name: "../evil.txt", typeflag: tar.TypeReg, body: "x"},
+		"absolute path":    {name: "/tmp/evil.txt", typeflag: tar.TypeReg, body: "x"},
+		"escaping link":    {name: "passwd", typeflag: tar.TypeSymlink, linkname: "../../../etc/passwd"},
+	}
+	for name, entry := range tests {
+		t.Run(name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "dest")
+			if err := ExtractTarGz(buildTarGz(t, []tarEntry{entry}), dest); err == nil {
+				t.Errorf("Expected %s to be rejected", entry.name)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil.txt")); err == nil {
+				t.Errorf("Entry was written outside the destination")
+			}
+		})
+	}
+}
+
+func TestExtractTarGzRejectsLinkChains(t *testing.T) {
+	tests := map[string][]tarEntry{
+		// Each link stays inside on its own, but together they climb out
+		"file through links": {
+			{name: "a", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "a/b/evil.txt", typeflag: tar.TypeReg, body: "x"},
+		},
+		"directory through links": {
+			{name: "a", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "a/b/evil.txt/", typeflag: tar.TypeDir},
+		},
+	}
+	for name, entries := range tests {
+		t.Run(name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "dest")
+			if err := ExtractTarGz(buildTarGz(t, entries), dest); err == nil {
+				t.Error("Expected the link chain to be rejected")
+			}
+			if _, err := os.Lstat(filepath.Join(filepath.Dir(dest), "evil.txt")); err == nil {
+				t.Errorf("Entry was written outside the destination")
+			}
+		})
+	}
+}
+
+func TestExtractTarGzReplacesLinkedFile(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	if err := os.WriteFile(outside, []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	dest := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dest, "file.txt")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	archive := buildTarGz(t, []tarEntry{{name: "file.txt", typeflag: tar.TypeReg, body: "new"}})
+	if err := ExtractTarGz(archive, dest); err != nil {
+		t.Fatalf("ExtractTarGz failed: %v", err)
+	}
+	if content, _ := os.ReadFile(outside); string(content) != "keep" {
+		t.Errorf("Expected the link target to be left alone, got %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dest, "file.txt")); string(content) != "new" {
+		t.Errorf("Expected the link to be replaced by the file, got %q", content)
+	}
+}
+
+func TestWriteTarGzRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "site", "assets"), 0755); err != nil {
+		t.Fatalf("Failed to create source tree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "site", "assets", "app.js"), []byte("run()"), 0600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Symlink("assets/app.js", filepath.Join(src, "site", "latest.js")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTarGz(&buf, src, []string{"site"}, SymlinkPreserve); err != nil {
+		t.Fatalf("WriteTarGz failed: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := ExtractTarGz(&buf, dest); err != nil {
+		t.Fatalf("ExtractTarGz failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "site", "assets", "app.js"))
+	if err != nil {
+		t.Fatalf("Expected file to round-trip: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 to be preserved, got %o", info.Mode().Perm())
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "site", "latest.js")); err != nil || target != "assets/app.js" {
+		t.Errorf("Expected symlink to be preserved, got %q (%v)", target, err)
+	}
+}
+
+func TestExtractCommand(t *testing.T) {
+	tests := map[string]string{
+		"backup.tar.gz":       `tar xzf '/srv/backup.tar.gz' -C '/srv'`,
+		"backup.TGZ":          `tar xzf '/srv/backup.TGZ' -C '/srv'`,
+		"logs.tar.xz":         `tar xJf '/srv/logs.tar.xz' -C '/srv'`,
+		"plain.tar":           `tar xf '/srv/plain.tar' -C '/srv'`,
+		"photos.zip":          `unzip -o -q '/srv/photos.zip' -d '/srv'`,
+		"notes.txt":           "",
+		"archive.tar.gz.part": "",
+	}
+	for name, expected := range tests {
+		if got := extractCommand(name, "/srv/"+name, "/srv"); got != expected {
+			t.Errorf("extractCommand(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestUploadExtractCommand(t *testing.T) {
+	if got := uploadExtractCommand("/srv/it's"); got != `tar xzof - -C '/srv/it'\''s'` {
+		t.Errorf("Unexpected command %q", got)
+	}
+}
+
+func TestDownloadArchiveFileSFTP(t *testing.T) {
+	client := newTestClient(t)
+	remoteDir := t.TempDir()
+	writeTree(t, remoteDir, map[string]string{"logs/app.log": "started\n", "notes.txt": "hello"})
+	if err := os.Symlink("notes.txt", filepath.Join(remoteDir, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "backup.tar.gz")
+	err := client.DownloadArchiveFileSFTP(remoteDir, []string{"logs", "notes.txt", "link"}, SymlinkSkip, archivePath)
+	if err != nil {
+		t.Fatalf("DownloadArchiveFileSFTP failed: %v", err)
+	}
+
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatalf("Expected the archive to be created: %v", err)
+	}
+	defer archive.Close()
+	dest := t.TempDir()
+	if err := ExtractTarGz(archive, dest); err != nil {
+		t.Fatalf("Failed to extract the archive: %v", err)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dest, "logs", "app.log")); err != nil || string(content) != "started\n" {
+		t.Errorf("Expected logs/app.log in the archive, got %q (%v)", content, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "link")); !os.IsNotExist(err) {
+		t.Errorf("Expected the skipped link to be left out, got %v", err)
+	}
+	if _, err := os.Stat(archivePath + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Expected no partial file to be left behind, got %v", err)
+	}
+}
+
+func TestArchiveStem(t *testing.T) {
+	tests := map[string]string{
+		"site.tar.gz":     "site",
+		"Backup.TGZ":      "Backup",
+		"v1.2.tar.xz":     "v1.2",
+		"photos.zip":      "photos",
+		"notes.txt":       "notes.txt",
+		"release.tar.bz2": "release",
+	}
+	for name, expected := range tests {
+		if got := ArchiveStem(name); got != expected {
+			t.Errorf("ArchiveStem(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package ssh
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// archiveFormat identifies how an archive is read
+type archiveFormat int
+
+const (
+	formatZip archiveFormat = iota
+	formatTar
+	formatTarGz
+)
+
+// Archive is a zip or tar archive opened for browsing like a directory tree
+type Archive struct {
+	format archiveFormat
+	reader io.ReaderAt
+	size   int64
+	closer io.Closer
+	zip    *zip.Reader
+	dirs   map[string][]FileInfo // Children of each directory, "" is the root
+
+	mu     sync.Mutex
+	users  int  // Copies still reading the archive
+	closed bool // Close was called, the file is closed once users drops to 0
+}
+
+// ErrArchiveClosed is returned when reading an archive that was closed
+var ErrArchiveClosed = errors.New("archive closed")
+
+// archiveFormatOf returns the format of an archive from its name
+func archiveFormatOf(name string) (archiveFormat, bool) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip, true
+	case strings.HasSuffix(lower, ".tar"):
+		return formatTar, true
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz, true
+	}
+	return 0, false
+}
+
+// IsBrowsableArchive reports whether a file can be opened with OpenLocalArchive or OpenRemoteArchive
+func IsBrowsableArchive(name string) bool {
+	_, ok := archiveFormatOf(name)
+	return ok
+}
+
+// OpenLocalArchive opens a local archive for browsing
+func OpenLocalArchive(localPath string) (*Archive, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	archive, err := openArchive(localPath, file, info.Size(), file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return archive, nil
+}
+
+// OpenRemoteArchive opens a remote archive for browsing. Zip archives are read
+// with random access, so only the central directory and the members that are
+// copied out are transferred.
+func (c *Client) OpenRemoteArchive(remotePath string) (*Archive, error) {
+	file, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	archive, err := openArchive(remotePath, file, info.Size(), file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return archive, nil
+}
+
+// openArchive reads the table of contents of an archive
+func openArchive(name string, r io.ReaderAt, size int64, closer io.Closer) (*Archive, error) {
+	format, ok := archiveFormatOf(name)
+	if !ok {
+		return nil, fmt.Errorf("unsupported archive format: %s", name)
+	}
+
+	a := &Archive{format: format, reader: r, size: size, closer: closer}
+	if format == formatZip {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip archive: %w", err)
+		}
+		a.zip = zr
+	}
+
+	entries := make(map[string]map[string]FileInfo)
+	addEntry := func(name string, info FileInfo) {
+		dir := path.Dir(name)
+		if dir == "." {
+			dir = ""
+		}
+		if entries[dir] == nil {
+			entries[dir] = make(map[string]FileInfo)
+		}
+		// Explicit directory entries replace the implicit ones created for their children
+		if _, exists := entries[dir][info.Name]; !exists || !info.ModTime.IsZero() {
+			entries[dir][info.Name] = info
+		}
+	}
+
+	err := a.walk(func(name string, info FileInfo, _ func() (io.Reader, error)) error {
+		for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+			addEntry(parent, FileInfo{Name: path.Base(parent), IsDir: true, Mode: os.ModeDir | 0755})
+		}
+		addEntry(name, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.dirs = make(map[string][]FileInfo)
+	for dir, children := range entries {
+		list := make([]FileInfo, 0, len(children))
+		for _, info := range children {
+			list = append(list, info)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		a.dirs[dir] = list
+	}
+	return a, nil
+}
+
+// List returns the entries of a directory inside the archive, "" being the root
+func (a *Archive) List(dir string) []FileInfo {
+	return a.dirs[dir]
+}
+
+// Close releases the underlying file, waiting for copies still reading it
+// (see Hold) to finish first
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	if a.users > 0 {
+		// The last copy reading the archive closes it
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// Hold keeps the archive open until release is called, even if it is closed
+// meanwhile, so a copy started before leaving the archive can finish. It
+// fails with ErrArchiveClosed once the underlying file was closed.
+func (a *Archive) Hold() (release func(), err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed && a.users == 0 {
+		return nil, ErrArchiveClosed
+	}
+	a.users++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.users--
+			if a.users == 0 && a.closed {
+				a.closer.Close()
+			}
+		})
+	}, nil
+}
+
+// CopyToLocal copies members of the archive, and everything below member
+// directories, into localDir without extracting the rest
+func (a *Archive) CopyToLocal(members []string, localDir string) error {
+	return a.copyOut(members, func(rel string, info FileInfo, r io.Reader) error {
+		target := filepath.Join(localDir, filepath.FromSlash(rel))
+		if info.IsDir {
+			if err := checkNoLinks(localDir, target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+			return nil
+		}
+		return extractFile(r, localDir, target, info.Mode.Perm())
+	})
+}
+
+// CopyToRemote copies members of the archive, and everything below member
+// directories, into remoteDir on the server
+func (a *Archive) CopyToRemote(client *Client, members []string, remoteDir string) error {
+	return a.copyOut(members, func(rel string, info FileInfo, r io.Reader) error {
+		target := path.Join(remoteDir, rel)
+		if info.IsDir {
+			if err := client.sftpClient.MkdirAll(target); err != nil {
+				return fmt.Errorf("failed to create remote directory %s: %w", target, err)
+			}
+			return nil
+		}
+		return writeRemoteFile(client.sftpClient, r, target, info.Mode.Perm())
+	})
+}
+
+// writeRemoteFile writes one archive member to the server
+func writeRemoteFile(client *sftp.Client, r io.Reader, target string, mode os.FileMode) error {
+	if err := client.MkdirAll(path.Dir(target)); err != nil {
+		return fmt.Errorf("failed to create remote directory for %s: %w", target, err)
+	}
+
+	file, err := client.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %s: %w", target, err)
+	}
+	defer file.Close()
+
+	if _, err := file.ReadFrom(r); err != nil {
+		return fmt.Errorf("failed to write remote file %s: %w", target, err)
+	}
+	if err := file.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set mode of %s: %w", target, err)
+	}
+	return nil
+}
+
+// copyOut calls write for every entry at or below the given members, with a
+// path relative to the directory containing the member. Links are skipped.
+func (a *Archive) copyOut(members []string, write func(rel string, info FileInfo, r io.Reader) error) error {
+	release, err := a.Hold()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return a.walk(func(name string, info FileInfo, open func() (io.Reader, error)) error {
+		if info.IsLink {
+			return nil
+		}
+		for _, member := range members {
+			if name != member && !strings.HasPrefix(name, member+"/") {
+				continue
+			}
+
+			rel := path.Join(path.Base(member), strings.TrimPrefix(name, member))
+			if info.IsDir {
+				return write(rel, info, nil)
+			}
+			r, err := open()
+			if err != nil {
+				return err
+			}
+			return write(rel, info, r)
+		}
+		return nil
+	})
+}
+
+// walk calls fn for every entry of the archive in storage order with its
+// cleaned path and a function to open its contents. Zip members are only
+// read when opened, which matters for remote archives.
+func (a *Archive) walk(fn func(name string, info FileInfo, open func() (io.Reader, error)) error) error {
+	if a.format == formatZip {
+		for _, f := range a.zip.File {
+			name := cleanArchivePath(f.Name)
+			if name == "" {
+				continue
+			}
+			info := FileInfo{
+				Name:    path.Base(name),
+				Size:    int64(f.UncompressedSize64),
+				ModTime: f.Modified,
+				IsDir:   f.FileInfo().IsDir(),
+				Mode:    f.Mode(),
+				IsLink:  f.Mode()&os.ModeSymlink != 0,
+			}
+
+			var rc io.ReadCloser
+			open := func() (io.Reader, error) {
+				var err error
+				if rc, err = f.Open(); err != nil {
+					return nil, fmt.Errorf("failed to read %s from archive: %w", f.Name, err)
+				}
+				return rc, nil
+			}
+			err := fn(name, info, open)
+			if rc != nil {
+				rc.Close()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var r io.Reader = io.NewSectionReader(a.reader, 0, a.size)
+	if a.format == formatTarGz {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name := cleanArchivePath(header.Name)
+		if name == "" {
+			continue
+		}
+		info := FileInfo{
+			Name:       path.Base(name),
+			Size:       header.Size,
+			ModTime:    header.ModTime,
+			IsDir:      header.Typeflag == tar.TypeDir,
+			Mode:       header.FileInfo().Mode(),
+			IsLink:     header.Typeflag == tar.TypeSymlink,
+			LinkTarget: header.Linkname,
+			Owner:      header.Uname,
+			Group:      header.Gname,
+		}
+		if info.IsDir || info.IsLink || header.Typeflag == tar.TypeReg {
+			open := func() (io.Reader, error) { return tr, nil }
+			if err := fn(name, info, open); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// cleanArchivePath normalizes an archive entry name to a relative slash
+// separated path that can't climb out of the archive, "" for the root
+func cleanArchivePath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, `\`, "/")), "/")
+}
@@ -0,0 +1,160 @@
+package ssh
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestZip creates a zip archive with the given files, creating no directory entries
+func writeTestZip(t *testing.T, zipPath string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to create zip: %v", err)
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to finish zip: %v", err)
+	}
+}
+
+func TestArchiveListing(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "release.zip")
+	writeTestZip(t, zipPath, map[string]string{
+		"README.md":         "hello",
+		"bin/app":           "binary",
+		"share/doc/app.txt": "docs",
+		"../../escape.txt":  "x",
+	})
+
+	archive, err := OpenLocalArchive(zipPath)
+	if err != nil {
+		t.Fatalf("OpenLocalArchive failed: %v", err)
+	}
+	defer archive.Close()
+
+	var names []string
+	for _, entry := range archive.List("") {
+		names = append(names, entry.Name)
+	}
+	expected := []string{"README.md", "bin", "escape.txt", "share"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected root entries %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected root entries %v, got %v", expected, names)
+			break
+		}
+	}
+
+	share := archive.List("share")
+	if len(share) != 1 || share[0].Name != "doc" || !share[0].IsDir {
+		t.Errorf("Expected implicit directory share/doc, got %+v", share)
+	}
+	doc := archive.List("share/doc")
+	if len(doc) != 1 || doc[0].Name != "app.txt" || doc[0].Size != 4 {
+		t.Errorf("Expected share/doc/app.txt of 4 bytes, got %+v", doc)
+	}
+}
+
+func TestArchiveCopyOut(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "site.tar.gz")
+	buf := buildTarGz(t, []tarEntry{
+		{name: "./site/", typeflag: tar.TypeDir},
+		{name: "./site/index.html", typeflag: tar.TypeReg, body: "<html>"},
+		{name: "./site/css/main.css", typeflag: tar.TypeReg, body: "body{}"},
+		{name: "./other.txt", typeflag: tar.TypeReg, body: "skip me"},
+	})
+	if err := os.WriteFile(tarPath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	archive, err := OpenLocalArchive(tarPath)
+	if err != nil {
+		t.Fatalf("OpenLocalArchive failed: %v", err)
+	}
+	defer archive.Close()
+
+	dest := t.TempDir()
+	if err := archive.CopyToLocal([]string{"site/css"}, dest); err != nil {
+		t.Fatalf("CopyToLocal failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dest, "css", "main.css"))
+	if err != nil || string(content) != "body{}" {
+		t.Errorf("Expected css/main.css to be copied, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "index.html")); err == nil {
+		t.Errorf("Expected only the selected member to be copied")
+	}
+}
+
+func TestArchiveHeldOpenUntilCopyFinishes(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "release.zip")
+	writeTestZip(t, zipPath, map[string]string{"README.md": "hello"})
+	archive, err := OpenLocalArchive(zipPath)
+	if err != nil {
+		t.Fatalf("OpenLocalArchive failed: %v", err)
+	}
+
+	// Leaving the archive while a copy holds it leaves it readable
+	release, err := archive.Hold()
+	if err != nil {
+		t.Fatalf("Hold failed: %v", err)
+	}
+	archive.Close()
+	dest := t.TempDir()
+	if err := archive.CopyToLocal([]string{"README.md"}, dest); err != nil {
+		t.Fatalf("Expected a held archive to stay readable: %v", err)
+	}
+	release()
+
+	if _, err := archive.Hold(); !errors.Is(err, ErrArchiveClosed) {
+		t.Errorf("Expected ErrArchiveClosed once closed, got %v", err)
+	}
+	if err := archive.CopyToLocal([]string{"README.md"}, dest); !errors.Is(err, ErrArchiveClosed) {
+		t.Errorf("Expected copies after closing to fail with ErrArchiveClosed, got %v", err)
+	}
+}
+
+func TestRemoteArchiveCopyToRemote(t *testing.T) {
+	client := newTestClient(t)
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "bundle.zip")
+	writeTestZip(t, zipPath, map[string]string{
+		"conf/app.yaml": "port: 80",
+		"conf/db.yaml":  "host: db",
+	})
+
+	archive, err := client.OpenRemoteArchive(zipPath)
+	if err != nil {
+		t.Fatalf("OpenRemoteArchive failed: %v", err)
+	}
+	defer archive.Close()
+
+	dest := filepath.Join(dir, "out")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatalf("Failed to create destination: %v", err)
+	}
+	if err := archive.CopyToRemote(client, []string{"conf/db.yaml"}, dest); err != nil {
+		t.Fatalf("CopyToRemote failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dest, "db.yaml"))
+	if err != nil || string(content) != "host: db" {
+		t.Errorf("Expected db.yaml to be copied, got %q (%v)", content, err)
+	}
+}
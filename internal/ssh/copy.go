@@ -1,10 +1,12 @@
 package ssh
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-
+	"path"
+	"path/filepath"
 )
 
 // CopyFile copies a single file from source to destination
@@ -59,9 +61,412 @@ func (c *Client) CopyFileToLocal(remotePath, localPath string) error {
 
 // CopyProgress represents copy progress information
 type CopyProgress struct {
-	FileName string
-	Current  int64
-	Total    int64
-	Index    int
+	FileName   string
+	Current    int64
+	Total      int64
+	Index      int
 	TotalFiles int
 }
+
+// SymlinkPolicy controls how symlinks are handled by recursive transfers
+type SymlinkPolicy int
+
+const (
+	SymlinkFollow   SymlinkPolicy = iota // Copy whatever the link points to
+	SymlinkPreserve                      // Recreate the link itself at the destination
+	SymlinkSkip                          // Leave links out of the transfer
+)
+
+// String returns a short name for the policy
+func (p SymlinkPolicy) String() string {
+	switch p {
+	case SymlinkPreserve:
+		return "preserve"
+	case SymlinkSkip:
+		return "skip"
+	default:
+		return "follow"
+	}
+}
+
+// Next returns the policy that follows p, for cycling through policies
+func (p SymlinkPolicy) Next() SymlinkPolicy {
+	return (p + 1) % 3
+}
+
+// UploadPath copies a local file or directory tree to remotePath,
+// handling symlinks according to policy
+func (c *Client) UploadPath(localPath, remotePath string, policy SymlinkPolicy) error {
+	return c.uploadPath(localPath, remotePath, policy, map[string]bool{})
+}
+
+func (c *Client) uploadPath(localPath, remotePath string, policy SymlinkPolicy, ancestors map[string]bool) error {
+	info, err := os.Lstat(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat local path: %w", err)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		switch policy {
+		case SymlinkSkip:
+			return nil
+		case SymlinkPreserve:
+			target, err := os.Readlink(localPath)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", localPath, err)
+			}
+			return c.createSymlink(target, remotePath)
+		}
+		if info, err = os.Stat(localPath); err != nil {
+			return fmt.Errorf("broken symlink %s: %w", localPath, err)
+		}
+	}
+
+	if !info.IsDir() {
+		return c.CopyFileFromLocal(localPath, remotePath)
+	}
+
+	realPath, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", localPath, err)
+	}
+	if ancestors[realPath] {
+		return fmt.Errorf("symlink loop detected at %s", localPath)
+	}
+	ancestors[realPath] = true
+	defer delete(ancestors, realPath)
+
+	if err := c.sftpClient.MkdirAll(remotePath); err != nil {
+		return fmt.Errorf("failed to create remote directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to list local directory: %w", err)
+	}
+	for _, entry := range entries {
+		err := c.uploadPath(filepath.Join(localPath, entry.Name()), path.Join(remotePath, entry.Name()), policy, ancestors)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DownloadPath copies a remote file or directory tree to localPath,
+// handling symlinks according to policy
+func (c *Client) DownloadPath(remotePath, localPath string, policy SymlinkPolicy) error {
+	return c.downloadPath(remotePath, c.remoteRealPath(remotePath), localPath, policy, map[string]bool{})
+}
+
+// downloadPath copies remotePath, whose canonical location is realPath, to localPath
+func (c *Client) downloadPath(remotePath, realPath, localPath string, policy SymlinkPolicy, ancestors map[string]bool) error {
+	info, err := c.sftpClient.Lstat(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat remote path: %w", err)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		switch policy {
+		case SymlinkSkip:
+			return nil
+		case SymlinkPreserve:
+			target, err := c.sftpClient.ReadLink(remotePath)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", remotePath, err)
+			}
+			return createLocalSymlink(target, localPath)
+		}
+		if info, err = c.sftpClient.Stat(remotePath); err != nil {
+			return fmt.Errorf("broken symlink %s: %w", remotePath, err)
+		}
+		realPath = c.resolveRemoteLink(remotePath, realPath)
+	}
+
+	if !info.IsDir() {
+		return c.CopyFileToLocal(remotePath, localPath)
+	}
+
+	if ancestors[realPath] {
+		return fmt.Errorf("symlink loop detected at %s", remotePath)
+	}
+	ancestors[realPath] = true
+	defer delete(ancestors, realPath)
+
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+
+	entries, err := c.sftpClient.ReadDir(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to list remote directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		err := c.downloadPath(path.Join(remotePath, name), path.Join(realPath, name), filepath.Join(localPath, name), policy, ancestors)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remoteRealPath returns the canonical form of a remote path, falling back to
+// a lexically cleaned path when the server can't resolve it
+func (c *Client) remoteRealPath(remotePath string) string {
+	if realPath, err := c.sftpClient.RealPath(remotePath); err == nil {
+		return realPath
+	}
+	return path.Clean(remotePath)
+}
+
+// resolveRemoteLink returns the canonical path a remote symlink points to,
+// given the canonical path of the link itself. Resolving relative targets here
+// rather than relying on the server keeps loop detection working on servers
+// whose realpath doesn't follow links.
+func (c *Client) resolveRemoteLink(linkPath, realLinkPath string) string {
+	target, err := c.sftpClient.ReadLink(linkPath)
+	if err != nil {
+		return realLinkPath
+	}
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(realLinkPath), target)
+	}
+	return c.remoteRealPath(target)
+}
+
+// createSymlink creates a remote symlink, replacing an existing file or link at remotePath
+func (c *Client) createSymlink(target, remotePath string) error {
+	if existing, err := c.sftpClient.Lstat(remotePath); err == nil && !existing.IsDir() {
+		if err := c.sftpClient.Remove(remotePath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", remotePath, err)
+		}
+	}
+	if err := c.sftpClient.Symlink(target, remotePath); err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// createLocalSymlink creates a local symlink, replacing an existing file or link at localPath
+func createLocalSymlink(target, localPath string) error {
+	if existing, err := os.Lstat(localPath); err == nil && !existing.IsDir() {
+		if err := os.Remove(localPath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", localPath, err)
+		}
+	}
+	if err := os.Symlink(target, localPath); err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// VerifyUpload checks that everything UploadPath copied from localPath exists
+// under remotePath with the same type and size
+func (c *Client) VerifyUpload(localPath, remotePath string, policy SymlinkPolicy) error {
+	return c.verifyUpload(localPath, remotePath, policy, map[string]bool{})
+}
+
+func (c *Client) verifyUpload(localPath, remotePath string, policy SymlinkPolicy, ancestors map[string]bool) error {
+	localInfo, err := os.Lstat(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat local path: %w", err)
+	}
+
+	if localInfo.Mode()&os.ModeSymlink != 0 {
+		switch policy {
+		case SymlinkSkip:
+			return nil
+		case SymlinkPreserve:
+			remoteInfo, err := c.sftpClient.Lstat(remotePath)
+			if err != nil || remoteInfo.Mode()&os.ModeSymlink == 0 {
+				return fmt.Errorf("copy verification failed: %s is not a symlink", remotePath)
+			}
+			return nil
+		}
+		if localInfo, err = os.Stat(localPath); err != nil {
+			return fmt.Errorf("broken symlink %s: %w", localPath, err)
+		}
+	}
+
+	remoteInfo, err := c.sftpClient.Stat(remotePath)
+	if err != nil {
+		return fmt.Errorf("missing remote copy of %s: %w", localPath, err)
+	}
+	if err := compareCopy(localInfo, remoteInfo, remotePath); err != nil {
+		return err
+	}
+	if !localInfo.IsDir() {
+		return nil
+	}
+
+	realPath, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", localPath, err)
+	}
+	if ancestors[realPath] {
+		return fmt.Errorf("symlink loop detected at %s", localPath)
+	}
+	ancestors[realPath] = true
+	defer delete(ancestors, realPath)
+
+	entries, err := os.ReadDir(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to list local directory: %w", err)
+	}
+	for _, entry := range entries {
+		err := c.verifyUpload(filepath.Join(localPath, entry.Name()), path.Join(remotePath, entry.Name()), policy, ancestors)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VerifyDownload checks that everything DownloadPath copied from remotePath
+// exists under localPath with the same type and size
+func (c *Client) VerifyDownload(remotePath, localPath string, policy SymlinkPolicy) error {
+	return c.verifyDownload(remotePath, c.remoteRealPath(remotePath), localPath, policy, map[string]bool{})
+}
+
+// verifyDownload verifies the copy of remotePath, whose canonical location is realPath
+func (c *Client) verifyDownload(remotePath, realPath, localPath string, policy SymlinkPolicy, ancestors map[string]bool) error {
+	remoteInfo, err := c.sftpClient.Lstat(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat remote path: %w", err)
+	}
+
+	if remoteInfo.Mode()&os.ModeSymlink != 0 {
+		switch policy {
+		case SymlinkSkip:
+			return nil
+		case SymlinkPreserve:
+			localInfo, err := os.Lstat(localPath)
+			if err != nil || localInfo.Mode()&os.ModeSymlink == 0 {
+				return fmt.Errorf("copy verification failed: %s is not a symlink", localPath)
+			}
+			return nil
+		}
+		if remoteInfo, err = c.sftpClient.Stat(remotePath); err != nil {
+			return fmt.Errorf("broken symlink %s: %w", remotePath, err)
+		}
+		realPath = c.resolveRemoteLink(remotePath, realPath)
+	}
+
+	localInfo, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("missing local copy of %s: %w", remotePath, err)
+	}
+	if err := compareCopy(remoteInfo, localInfo, localPath); err != nil {
+		return err
+	}
+	if !remoteInfo.IsDir() {
+		return nil
+	}
+
+	if ancestors[realPath] {
+		return fmt.Errorf("symlink loop detected at %s", remotePath)
+	}
+	ancestors[realPath] = true
+	defer delete(ancestors, realPath)
+
+	entries, err := c.sftpClient.ReadDir(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to list remote directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		err := c.verifyDownload(path.Join(remotePath, name), path.Join(realPath, name), filepath.Join(localPath, name), policy, ancestors)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compareCopy checks that a copy has the same type and size as its source
+func compareCopy(source, dest os.FileInfo, destPath string) error {
+	if source.IsDir() != dest.IsDir() {
+		return fmt.Errorf("copy verification failed: %s has the wrong type", destPath)
+	}
+	if !source.IsDir() && source.Size() != dest.Size() {
+		return fmt.Errorf("copy verification failed: %s is %d bytes, expected %d",
+			destPath, dest.Size(), source.Size())
+	}
+	return nil
+}
+
+// maxLinkHops limits how many symlinks are followed to find a file, like the
+// ELOOP limit of most systems
+const maxLinkHops = 40
+
+// resolveRemoteFile returns the path of the file remotePath refers to once all
+// symlinks are followed. A path that doesn't exist resolves to itself.
+func (c *Client) resolveRemoteFile(remotePath string) (string, error) {
+	target := c.remoteRealPath(remotePath)
+	for range maxLinkHops {
+		info, err := c.sftpClient.Lstat(target)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return target, nil
+		}
+		// The server's realpath didn't follow the link
+		target = c.resolveRemoteLink(target, target)
+	}
+	return "", fmt.Errorf("too many levels of symlinks at %s", remotePath)
+}
+
+// UploadAtomic uploads a local file over remotePath without leaving a partially
+// written file behind: the data goes to a temporary file in the same directory,
+// which gets the given mode and the owner of the file it replaces and is then
+// renamed over the destination. When remotePath is a symlink the file it
+// points to is replaced, leaving the link in place.
+func (c *Client) UploadAtomic(localPath, remotePath string, mode os.FileMode) error {
+	target, err := c.resolveRemoteFile(remotePath)
+	if err != nil {
+		return err
+	}
+	tempPath := path.Join(path.Dir(target), fmt.Sprintf(".%s.sshlepp-%d", path.Base(target), os.Getpid()))
+
+	if err := c.CopyFileFromLocal(localPath, tempPath); err != nil {
+		c.sftpClient.Remove(tempPath)
+		return err
+	}
+	if err := c.sftpClient.Chmod(tempPath, mode); err != nil {
+		c.sftpClient.Remove(tempPath)
+		return fmt.Errorf("failed to set mode on uploaded file: %w", err)
+	}
+	// Only root can give files away, so other users keep their own ownership
+	if info, err := c.sftpClient.Stat(target); err == nil {
+		perms := remotePermissions(info)
+		if perms.UID >= 0 && perms.GID >= 0 {
+			err := c.sftpClient.Chown(tempPath, perms.UID, perms.GID)
+			if err != nil && !errors.Is(err, os.ErrPermission) {
+				c.sftpClient.Remove(tempPath)
+				return fmt.Errorf("failed to set owner on uploaded file: %w", err)
+			}
+		}
+	}
+
+	// posix-rename replaces the destination in one step; plain SFTP rename
+	// refuses to overwrite, so fall back to removing the old file first
+	if _, ok := c.sftpClient.HasExtension("posix-rename@openssh.com"); ok {
+		if err := c.sftpClient.PosixRename(tempPath, target); err != nil {
+			c.sftpClient.Remove(tempPath)
+			return fmt.Errorf("failed to replace %s: %w", target, err)
+		}
+		return nil
+	}
+
+	if err := c.sftpClient.Remove(target); err != nil && !os.IsNotExist(err) {
+		c.sftpClient.Remove(tempPath)
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	if err := c.sftpClient.Rename(tempPath, target); err != nil {
+		return fmt.Errorf("failed to replace %s, upload kept at %s: %w", target, tempPath, err)
+	}
+	return nil
+}
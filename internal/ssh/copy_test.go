@@ -0,0 +1,329 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestClient returns a Client backed by an in-process SFTP server that
+// serves the local filesystem
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter})
+	if err != nil {
+		t.Fatalf("Failed to create SFTP server: %v", err)
+	}
+	go server.Serve()
+
+	sftpClient, err := sftp.NewClientPipe(clientReader, clientWriter)
+	if err != nil {
+		t.Fatalf("Failed to create SFTP client: %v", err)
+	}
+
+	client := &Client{sftpClient: sftpClient}
+	t.Cleanup(func() {
+		// Closing the pipes first lets both ends stop reading
+		clientWriter.Close()
+		serverWriter.Close()
+		client.Close()
+	})
+	return client
+}
+
+func TestUploadPathSymlinkPolicies(t *testing.T) {
+	client := newTestClient(t)
+	tempDir := t.TempDir()
+
+	source := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(filepath.Join(source, "dir"), 0755); err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "dir", "file.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Symlink("dir", filepath.Join(source, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	for _, policy := range []SymlinkPolicy{SymlinkFollow, SymlinkPreserve, SymlinkSkip} {
+		dest := filepath.Join(tempDir, "dest-"+policy.String())
+		if err := client.UploadPath(source, dest, policy); err != nil {
+			t.Fatalf("UploadPath with %s failed: %v", policy, err)
+		}
+		if err := client.VerifyUpload(source, dest, policy); err != nil {
+			t.Errorf("VerifyUpload with %s failed: %v", policy, err)
+		}
+
+		info, err := os.Lstat(filepath.Join(dest, "link"))
+		switch policy {
+		case SymlinkFollow:
+			if err != nil || !info.IsDir() {
+				t.Errorf("Expected followed link to be copied as a directory, got %v, %v", info, err)
+			}
+		case SymlinkPreserve:
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("Expected preserved link to be a symlink, got %v, %v", info, err)
+			}
+		case SymlinkSkip:
+			if !os.IsNotExist(err) {
+				t.Errorf("Expected skipped link to be absent, got %v", err)
+			}
+		}
+	}
+}
+
+func TestUploadPathSymlinkLoop(t *testing.T) {
+	client := newTestClient(t)
+	tempDir := t.TempDir()
+
+	source := filepath.Join(tempDir, "source")
+	if err := os.Mkdir(source, 0755); err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	if err := os.Symlink("..", filepath.Join(source, "parent")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(".", filepath.Join(source, "self")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	destDir := t.TempDir()
+
+	err := client.UploadPath(source, filepath.Join(destDir, "dest"), SymlinkFollow)
+	if err == nil || !strings.Contains(err.Error(), "symlink loop") {
+		t.Errorf("Expected symlink loop error, got %v", err)
+	}
+
+	err = client.DownloadPath(source, filepath.Join(destDir, "download"), SymlinkFollow)
+	if err == nil || !strings.Contains(err.Error(), "symlink loop") {
+		t.Errorf("Expected symlink loop error on download, got %v", err)
+	}
+}
+
+// writeTree creates files under root from a map of slash-separated paths to contents
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+}
+
+func TestUploadDownloadRoundTrip(t *testing.T) {
+	client := newTestClient(t)
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"a.txt":         "alpha",
+		"sub/b.txt":     "bravo",
+		"sub/deep/c.md": "charlie",
+		"empty.txt":     "",
+	}
+	source := filepath.Join(tempDir, "source")
+	writeTree(t, source, files)
+
+	remote := filepath.Join(tempDir, "remote")
+	if err := client.UploadPath(source, remote, SymlinkFollow); err != nil {
+		t.Fatalf("UploadPath failed: %v", err)
+	}
+	if err := client.VerifyUpload(source, remote, SymlinkFollow); err != nil {
+		t.Errorf("VerifyUpload failed: %v", err)
+	}
+
+	back := filepath.Join(tempDir, "back")
+	if err := client.DownloadPath(remote, back, SymlinkFollow); err != nil {
+		t.Fatalf("DownloadPath failed: %v", err)
+	}
+	if err := client.VerifyDownload(remote, back, SymlinkFollow); err != nil {
+		t.Errorf("VerifyDownload failed: %v", err)
+	}
+
+	for name, expected := range files {
+		data, err := os.ReadFile(filepath.Join(back, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("Failed to read %s after the round trip: %v", name, err)
+		} else if string(data) != expected {
+			t.Errorf("%s = %q after the round trip, expected %q", name, data, expected)
+		}
+	}
+}
+
+func TestVerifyUploadMismatch(t *testing.T) {
+	client := newTestClient(t)
+	tempDir := t.TempDir()
+
+	source := filepath.Join(tempDir, "source")
+	writeTree(t, source, map[string]string{"a.txt": "alpha", "sub/b.txt": "bravo"})
+
+	tests := []struct {
+		name   string
+		damage func(remote string) error
+		want   string
+	}{
+		{"truncated file", func(remote string) error {
+			return os.Truncate(filepath.Join(remote, "a.txt"), 2)
+		}, "is 2 bytes, expected 5"},
+		{"missing file", func(remote string) error {
+			return os.Remove(filepath.Join(remote, "sub", "b.txt"))
+		}, "missing remote copy"},
+		{"wrong type", func(remote string) error {
+			if err := os.RemoveAll(filepath.Join(remote, "sub")); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(remote, "sub"), nil, 0644)
+		}, "has the wrong type"},
+	}
+	for i, tt := range tests {
+		remote := filepath.Join(tempDir, fmt.Sprintf("remote%d", i))
+		if err := client.UploadPath(source, remote, SymlinkFollow); err != nil {
+			t.Fatalf("UploadPath failed: %v", err)
+		}
+		if err := tt.damage(remote); err != nil {
+			t.Fatalf("Failed to damage the copy for %s: %v", tt.name, err)
+		}
+
+		if err := client.VerifyUpload(source, remote, SymlinkFollow); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("VerifyUpload with a %s = %v, expected an error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestUploadPathPartialFailure(t *testing.T) {
+	client := newTestClient(t)
+	tempDir := t.TempDir()
+
+	source := filepath.Join(tempDir, "source")
+	writeTree(t, source, map[string]string{"a.txt": "alpha", "b/c.txt": "charlie"})
+
+	// A file where the copy needs a directory makes the transfer fail halfway
+	remote := filepath.Join(tempDir, "remote")
+	writeTree(t, remote, map[string]string{"b": "in the way"})
+
+	if err := client.UploadPath(source, remote, SymlinkFollow); err == nil {
+		t.Fatal("Expected UploadPath to fail when a directory can't be created")
+	}
+	if err := client.VerifyUpload(source, remote, SymlinkFollow); err == nil {
+		t.Error("Expected VerifyUpload to reject the incomplete copy")
+	}
+
+	back := filepath.Join(tempDir, "back")
+	writeTree(t, back, map[string]string{"b": "in the way"})
+	if err := client.DownloadPath(source, back, SymlinkFollow); err == nil {
+		t.Fatal("Expected DownloadPath to fail when a directory can't be created")
+	}
+	if err := client.VerifyDownload(source, back, SymlinkFollow); err == nil {
+		t.Error("Expected VerifyDownload to reject the incomplete copy")
+	}
+}
+
+func TestCompareCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTree(t, tempDir, map[string]string{"five": "12345", "also-five": "abcde", "three": "123"})
+	if err := os.Mkdir(filepath.Join(tempDir, "dir"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	stat := func(name string) os.FileInfo {
+		info, err := os.Stat(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", name, err)
+		}
+		return info
+	}
+
+	if err := compareCopy(stat("five"), stat("also-five"), "dest"); err != nil {
+		t.Errorf("Expected files of the same size to match, got %v", err)
+	}
+	if err := compareCopy(stat("dir"), stat("dir"), "dest"); err != nil {
+		t.Errorf("Expected directories to match, got %v", err)
+	}
+	if err := compareCopy(stat("five"), stat("three"), "dest"); err == nil {
+		t.Error("Expected files of different sizes not to match")
+	}
+	if err := compareCopy(stat("five"), stat("dir"), "dest"); err == nil {
+		t.Error("Expected a file and a directory not to match")
+	}
+}
+
+func TestExists(t *testing.T) {
+	client := newTestClient(t)
+	tempDir := t.TempDir()
+	writeTree(t, tempDir, map[string]string{"file": "x"})
+	if err := os.Symlink("missing", filepath.Join(tempDir, "dangling")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		exists bool
+	}{{"file", true}, {"dangling", true}, {"missing", false}} {
+		target := filepath.Join(tempDir, tt.name)
+		if exists, err := client.Exists(target); err != nil || exists != tt.exists {
+			t.Errorf("Exists(%s) = %v, %v, expected %v", tt.name, exists, err, tt.exists)
+		}
+		if exists, err := LocalExists(target); err != nil || exists != tt.exists {
+			t.Errorf("LocalExists(%s) = %v, %v, expected %v", tt.name, exists, err, tt.exists)
+		}
+	}
+}
+
+func TestUploadAtomicThroughSymlink(t *testing.T) {
+	client := newTestClient(t)
+	tempDir := t.TempDir()
+
+	writeTree(t, tempDir, map[string]string{"real.txt": "old", "edited.txt": "new content"})
+	real := filepath.Join(tempDir, "real.txt")
+	link := filepath.Join(tempDir, "link.txt")
+	if err := os.Symlink("real.txt", link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	// Only root can change owners, so check that they are kept when running as root
+	owned := os.Geteuid() == 0
+	if owned {
+		if err := os.Chown(real, 1234, 5678); err != nil {
+			t.Fatalf("Failed to chown: %v", err)
+		}
+	}
+
+	if err := client.UploadAtomic(filepath.Join(tempDir, "edited.txt"), link, 0640); err != nil {
+		t.Fatalf("UploadAtomic failed: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected the link to stay a symlink, got %v, %v", info, err)
+	}
+	data, err := os.ReadFile(real)
+	if err != nil || string(data) != "new content" {
+		t.Errorf("Expected the link target to be replaced, got %q, %v", data, err)
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		t.Fatalf("Failed to stat the link target: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected mode 0640, got %o", info.Mode().Perm())
+	}
+	if owned {
+		perms := localPermissions(info)
+		if perms.UID != 1234 || perms.GID != 5678 {
+			t.Errorf("Expected owner 1234:5678 to be kept, got %d:%d", perms.UID, perms.GID)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package ssh
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// toERE rewrites a Go regular expression as a POSIX extended regular
+// expression matching the same lines, so that grep -E on the server finds
+// what the SFTP search would. Constructs that ERE has no portable equivalent
+// for are rejected.
+func toERE(pattern string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	return ereNode(re)
+}
+
+// ereNode converts a parsed expression
+func ereNode(re *syntax.Regexp) (string, error) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return "", fmt.Errorf("an expression that never matches isn't supported by grep")
+	case syntax.OpEmptyMatch:
+		return "", nil
+	case syntax.OpLiteral:
+		var s strings.Builder
+		for _, r := range re.Rune {
+			lit, err := ereLiteral(r, re.Flags&syntax.FoldCase != 0)
+			if err != nil {
+				return "", err
+			}
+			s.WriteString(lit)
+		}
+		return s.String(), nil
+	case syntax.OpCharClass:
+		return ereClass(re.Rune)
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		return ".", nil
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		return "^", nil
+	case syntax.OpEndLine, syntax.OpEndText:
+		return "$", nil
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return "", fmt.Errorf(`\b and \B aren't supported by grep`)
+	case syntax.OpCapture:
+		sub, err := ereNode(re.Sub[0])
+		if err != nil || sub == "" {
+			return sub, err
+		}
+		return "(" + sub + ")", nil
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		return ereRepeat(re)
+	case syntax.OpConcat:
+		var s strings.Builder
+		for _, sub := range re.Sub {
+			part, err := ereConcatPart(sub)
+			if err != nil {
+				return "", err
+			}
+			s.WriteString(part)
+		}
+		return s.String(), nil
+	case syntax.OpAlternate:
+		s, _, err := ereAlternate(re)
+		return s, err
+	}
+	return "", fmt.Errorf("%s isn't supported by grep", re)
+}
+
+// ereAlternate converts an alternation. Empty branches make the whole
+// alternation optional, in which case it comes back grouped as (...)?.
+func ereAlternate(re *syntax.Regexp) (string, bool, error) {
+	var branches []string
+	optional := false
+	for _, sub := range re.Sub {
+		branch, err := ereNode(sub)
+		if err != nil {
+			return "", false, err
+		}
+		if branch == "" {
+			optional = true
+			continue
+		}
+		branches = append(branches, branch)
+	}
+
+	s := strings.Join(branches, "|")
+	if optional && s != "" {
+		return "(" + s + ")?", true, nil
+	}
+	return s, false, nil
+}
+
+// ereConcatPart converts an expression that is followed or preceded by
+// others, grouping alternations so the | doesn't take in the neighbours
+func ereConcatPart(re *syntax.Regexp) (string, error) {
+	if re.Op != syntax.OpAlternate {
+		return ereNode(re)
+	}
+	s, grouped, err := ereAlternate(re)
+	if err != nil || grouped || s == "" {
+		return s, err
+	}
+	return "(" + s + ")", nil
+}
+
+// ereRepeat converts *, +, ? and {n,m}. Non-greedy repeats become greedy
+// ones, which match the same lines.
+func ereRepeat(re *syntax.Regexp) (string, error) {
+	sub, err := ereNode(re.Sub[0])
+	if err != nil || sub == "" {
+		return "", err
+	}
+	if ereNeedsGroup(re.Sub[0]) {
+		sub = "(" + sub + ")"
+	}
+
+	switch re.Op {
+	case syntax.OpStar:
+		return sub + "*", nil
+	case syntax.OpPlus:
+		return sub + "+", nil
+	case syntax.OpQuest:
+		return sub + "?", nil
+	}
+	switch {
+	case re.Max == 0:
+		return "", nil
+	case re.Max == re.Min:
+		return sub + "{" + strconv.Itoa(re.Min) + "}", nil
+	case re.Max < 0:
+		return sub + "{" + strconv.Itoa(re.Min) + ",}", nil
+	}
+	return fmt.Sprintf("%s{%d,%d}", sub, re.Min, re.Max), nil
+}
+
+// ereNeedsGroup reports whether an expression has to be parenthesised before
+// a repetition operator can apply to all of it
+func ereNeedsGroup(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune) > 1
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpCapture:
+		return false
+	}
+	return true
+}
+
+// ereLiteral converts a single literal character
+func ereLiteral(r rune, foldCase bool) (string, error) {
+	if r == '\n' {
+		return "", fmt.Errorf("grep can't match line breaks")
+	}
+	if foldCase && r < utf8.RuneSelf && unicode.IsLetter(r) {
+		return "[" + string(unicode.ToUpper(r)) + string(unicode.ToLower(r)) + "]", nil
+	}
+	if strings.ContainsRune(`.[\()*+?{|^$`, r) {
+		return `\` + string(r), nil
+	}
+	return string(r), nil
+}
+
+// ereClass converts a character class given as sorted pairs of inclusive
+// ranges. Line breaks are left out since grep matches one line at a time.
+func ereClass(ranges []rune) (string, error) {
+	ranges = classWithout(ranges, '\n')
+	negated := false
+	if len(ranges) > 0 && ranges[len(ranges)-1] == unicode.MaxRune {
+		ranges = classWithout(classComplement(ranges), '\n')
+		negated = true
+	}
+
+	switch {
+	case len(ranges) == 0 && negated:
+		return ".", nil
+	case len(ranges) == 0:
+		return "", fmt.Errorf("an expression that never matches isn't supported by grep")
+	case ranges[len(ranges)-1] >= utf8.RuneSelf:
+		return "", fmt.Errorf("character classes with non-ASCII characters aren't supported by grep")
+	case !negated && len(ranges) == 2 && ranges[0] == ranges[1]:
+		return ereLiteral(ranges[0], false)
+	}
+
+	// ], ^, - and [ are special inside brackets depending on where they appear,
+	// so they are taken out of the ranges and put where they are literal
+	var body strings.Builder
+	special := map[rune]bool{}
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		for lo <= hi {
+			if strings.ContainsRune("]^-[", lo) {
+				special[lo] = true
+				lo++
+				continue
+			}
+			end := lo
+			for end < hi && !strings.ContainsRune("]^-[", end+1) {
+				end++
+			}
+			body.WriteRune(lo)
+			if end > lo+1 {
+				body.WriteRune('-')
+			}
+			if end > lo {
+				body.WriteRune(end)
+			}
+			lo = end + 1
+		}
+	}
+
+	var s strings.Builder
+	s.WriteString("[")
+	if negated {
+		s.WriteString("^")
+	}
+	if special[']'] {
+		s.WriteString("]")
+	}
+	s.WriteString(body.String())
+	if special['['] {
+		s.WriteString("[")
+	}
+	if special['^'] {
+		if s.Len() == 1 {
+			// A leading ^ would negate the class
+			if !special['-'] {
+				return `\^`, nil
+			}
+			s.WriteString("-")
+			delete(special, '-')
+		}
+		s.WriteString("^")
+	}
+	if special['-'] {
+		s.WriteString("-")
+	}
+	s.WriteString("]")
+	return s.String(), nil
+}
+
+// classWithout removes a character from a class
+func classWithout(ranges []rune, r rune) []rune {
+	var out []rune
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if r < lo || r > hi {
+			out = append(out, lo, hi)
+			continue
+		}
+		if lo < r {
+			out = append(out, lo, r-1)
+		}
+		if r < hi {
+			out = append(out, r+1, hi)
+		}
+	}
+	return out
+}
+
+// classComplement returns the characters not in a class
+func classComplement(ranges []rune) []rune {
+	var out []rune
+	next := rune(0)
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] > next {
+			out = append(out, next, ranges[i]-1)
+		}
+		next = ranges[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, next, unicode.MaxRune)
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxCommandOutput limits how much of each output stream of a remote command is kept
+const maxCommandOutput = 1024 * 1024
+
+// CommandResult is the outcome of a remote command
+type CommandResult struct {
+	Stdout     []byte
+	Stderr     []byte
+	ExitStatus int
+	Truncated  bool // Output exceeded maxCommandOutput and was cut off
+}
+
+// RunCommand runs a shell command in dir on the remote host over an exec session
+func (c *Client) RunCommand(dir, command string) (CommandResult, error) {
+	return c.RunCommandContext(context.Background(), dir, command)
+}
+
+// RunCommandContext runs a shell command like RunCommand. Cancelling ctx stops
+// the command, returning the output collected so far along with ctx's error.
+func (c *Client) RunCommandContext(ctx context.Context, dir, command string) (CommandResult, error) {
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	stdout := &cappedBuffer{limit: maxCommandOutput}
+	stderr := &cappedBuffer{limit: maxCommandOutput}
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if err := session.Start(fmt.Sprintf("cd %s && %s", ShellQuote(dir), command)); err != nil {
+		return CommandResult{}, fmt.Errorf("failed to run remote command: %w", err)
+	}
+	// Servers that accept signals stop the command at once, and closing the
+	// session hangs up on it for the others
+	stop := context.AfterFunc(ctx, func() {
+		session.Signal(ssh.SIGTERM)
+		session.Close()
+	})
+	defer stop()
+	err = session.Wait()
+
+	result := CommandResult{
+		Stdout:    stdout.Bytes(),
+		Stderr:    stderr.Bytes(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return result, ctxErr
+	}
+
+	var exitErr *ssh.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitStatus = exitErr.ExitStatus()
+	case err != nil:
+		return CommandResult{}, fmt.Errorf("failed to run remote command: %w", err)
+	}
+	return result, nil
+}
+
+// ExpandCommand replaces the placeholders in a command template: %d with the
+// directory, %f with the file names and %% with a literal percent sign. All
+// substituted values are shell-quoted.
+func ExpandCommand(template, dir string, files []string) string {
+	quoted := make([]string, len(files))
+	for i, file := range files {
+		quoted[i] = ShellQuote(file)
+	}
+
+	var s strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' || i == len(template)-1 {
+			s.WriteByte(template[i])
+			continue
+		}
+
+		switch template[i+1] {
+		case 'd':
+			s.WriteString(ShellQuote(dir))
+		case 'f':
+			s.WriteString(strings.Join(quoted, " "))
+		case '%':
+			s.WriteByte('%')
+		default:
+			s.WriteByte('%')
+			continue
+		}
+		i++
+	}
+	return s.String()
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write stores as much of p as fits, always reporting success so the command isn't interrupted
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		b.Buffer.Write(p[:max(0, room)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,30 @@
+package ssh
+
+import "testing"
+
+func TestExpandCommand(t *testing.T) {
+	files := []string{"a.log", "it's here.txt"}
+	tests := map[string]string{
+		"ls -l %f":        `ls -l 'a.log' 'it'\''s here.txt'`,
+		"du -sh %d":       `du -sh '/var/my logs'`,
+		"echo 100%% done": `echo 100% done`,
+		"date +%Y %":      `date +%Y %`,
+	}
+	for template, expected := range tests {
+		if got := ExpandCommand(template, "/var/my logs", files); got != expected {
+			t.Errorf("ExpandCommand(%q) = %s, expected %s", template, got, expected)
+		}
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{limit: 5}
+	for _, chunk := range []string{"abc", "defg", "h"} {
+		if n, err := b.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if b.String() != "abcde" || !b.truncated {
+		t.Errorf("Expected 'abcde' and truncated, got %q (truncated=%v)", b.String(), b.truncated)
+	}
+}
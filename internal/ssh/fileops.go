@@ -0,0 +1,134 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+)
+
+// Remove removes a remote file or empty directory
+func (c *Client) Remove(remotePath string) error {
+	if err := c.sftpClient.Remove(remotePath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// RemoveAll removes a remote path and everything it contains.
+// Symlinks are removed without following them.
+func (c *Client) RemoveAll(remotePath string) error {
+	info, err := c.sftpClient.Lstat(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+
+	if info.IsDir() {
+		entries, err := c.sftpClient.ReadDir(remotePath)
+		if err != nil {
+			return fmt.Errorf("failed to list directory %s: %w", remotePath, err)
+		}
+		for _, entry := range entries {
+			if err := c.RemoveAll(path.Join(remotePath, entry.Name())); err != nil {
+				return err
+			}
+		}
+		if err := c.sftpClient.RemoveDirectory(remotePath); err != nil {
+			return fmt.Errorf("failed to remove directory %s: %w", remotePath, err)
+		}
+		return nil
+	}
+
+	return c.Remove(remotePath)
+}
+
+// Rename renames a remote file or directory
+func (c *Client) Rename(oldPath, newPath string) error {
+	if err := c.sftpClient.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
+	}
+	return nil
+}
+
+// Mkdir creates a remote directory
+func (c *Client) Mkdir(remotePath string) error {
+	if err := c.sftpClient.Mkdir(remotePath); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// MkdirAll creates a remote directory along with any missing parents
+func (c *Client) MkdirAll(remotePath string) error {
+	if err := c.sftpClient.MkdirAll(remotePath); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// Exists reports whether a remote path exists, without following symlinks
+func (c *Client) Exists(remotePath string) (bool, error) {
+	_, err := c.sftpClient.Lstat(remotePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	return true, nil
+}
+
+// RemoveLocal removes a local file or empty directory
+func RemoveLocal(localPath string) error {
+	if err := os.Remove(localPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// RemoveAllLocal removes a local path and everything it contains
+func RemoveAllLocal(localPath string) error {
+	if _, err := os.Lstat(localPath); err != nil {
+		return fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
+	if err := os.RemoveAll(localPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// RenameLocal renames a local file or directory
+func RenameLocal(oldPath, newPath string) error {
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
+	}
+	return nil
+}
+
+// MkdirLocal creates a local directory
+func MkdirLocal(localPath string) error {
+	if err := os.Mkdir(localPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// MkdirAllLocal creates a local directory along with any missing parents
+func MkdirAllLocal(localPath string) error {
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// LocalExists reports whether a local path exists, without following symlinks
+func LocalExists(localPath string) (bool, error) {
+	_, err := os.Lstat(localPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,48 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalFileOps(t *testing.T) {
+	tempDir := t.TempDir()
+
+	nested := filepath.Join(tempDir, "a", "b", "c")
+	if err := MkdirAllLocal(nested); err != nil {
+		t.Fatalf("MkdirAllLocal failed: %v", err)
+	}
+
+	if err := MkdirLocal(filepath.Join(tempDir, "a")); err == nil {
+		t.Errorf("Expected MkdirLocal to fail for an existing directory")
+	}
+
+	file := filepath.Join(nested, "file.txt")
+	if err := os.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	renamed := filepath.Join(nested, "renamed.txt")
+	if err := RenameLocal(file, renamed); err != nil {
+		t.Fatalf("RenameLocal failed: %v", err)
+	}
+	if _, err := os.Stat(renamed); err != nil {
+		t.Errorf("Expected renamed file to exist: %v", err)
+	}
+
+	if err := RemoveLocal(filepath.Join(tempDir, "a")); err == nil {
+		t.Errorf("Expected RemoveLocal to fail for a non-empty directory")
+	}
+
+	if err := RemoveAllLocal(filepath.Join(tempDir, "a")); err != nil {
+		t.Fatalf("RemoveAllLocal failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "a")); !os.IsNotExist(err) {
+		t.Errorf("Expected directory to be removed, got %v", err)
+	}
+
+	if err := RemoveAllLocal(filepath.Join(tempDir, "missing")); err == nil {
+		t.Errorf("Expected RemoveAllLocal to fail for a missing path")
+	}
+}
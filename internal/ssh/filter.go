@@ -0,0 +1,27 @@
+package ssh
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FuzzyMatch reports whether the characters of pattern appear in name in
+// order, ignoring case, so "cfgprd" matches "config.prod.yaml". Plain
+// substrings always match.
+func FuzzyMatch(name, pattern string) bool {
+	remaining := []rune(strings.ToLower(pattern))
+	for _, r := range name {
+		if len(remaining) == 0 {
+			break
+		}
+		if unicode.ToLower(r) == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+	return len(remaining) == 0
+}
+
+// IsHidden reports whether a file is a dotfile
+func IsHidden(file FileInfo) bool {
+	return strings.HasPrefix(file.Name, ".")
+}
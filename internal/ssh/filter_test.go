@@ -0,0 +1,24 @@
+package ssh
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		expected bool
+	}{
+		{"config.prod.yaml", "", true},
+		{"config.prod.yaml", "prod", true},
+		{"config.prod.yaml", "PROD", true},
+		{"config.prod.yaml", "cfgprd", true},
+		{"config.prod.yaml", "prodcfg", false},
+		{"Überblick.md", "üb", true},
+		{"notes.txt", "notes.txt.bak", false},
+	}
+	for _, test := range tests {
+		if got := FuzzyMatch(test.name, test.pattern); got != test.expected {
+			t.Errorf("FuzzyMatch(%q, %q) = %v, expected %v", test.name, test.pattern, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FindQuery describes which files a recursive search matches
+type FindQuery struct {
+	Pattern   string         // Glob matched against file names, "" matches everything
+	Regexp    *regexp.Regexp // Used instead of Pattern when set
+	MinSize   int64          // -1 when unset
+	MaxSize   int64          // -1 when unset
+	NewerThan time.Duration  // Modified within this long, 0 when unset
+	OlderThan time.Duration  // Modified longer ago than this, 0 when unset
+}
+
+// FindResult is a file found by a search
+type FindResult struct {
+	Path string // Full path of the file
+	Info FileInfo
+}
+
+// ParseFindQuery parses a search such as "*.log size>10M mtime<7d". The name
+// pattern is a glob, or a regular expression when written as /regexp/.
+// Sizes accept K, M and G suffixes; ages accept s, m, h, d and w.
+func ParseFindQuery(input string) (FindQuery, error) {
+	query := FindQuery{MinSize: -1, MaxSize: -1}
+
+	for _, field := range strings.Fields(input) {
+		switch {
+		case strings.HasPrefix(field, "size>"), strings.HasPrefix(field, "size<"):
+			size, err := parseSize(field[5:])
+			if err != nil {
+				return FindQuery{}, err
+			}
+			if field[4] == '>' {
+				query.MinSize = size + 1
+			} else {
+				query.MaxSize = size - 1
+			}
+
+		case strings.HasPrefix(field, "mtime>"), strings.HasPrefix(field, "mtime<"):
+			age, err := parseAge(field[6:])
+			if err != nil {
+				return FindQuery{}, err
+			}
+			if field[5] == '<' {
+				query.NewerThan = age
+			} else {
+				query.OlderThan = age
+			}
+
+		case len(field) > 1 && strings.HasPrefix(field, "/") && strings.HasSuffix(field, "/"):
+			re, err := regexp.Compile(field[1 : len(field)-1])
+			if err != nil {
+				return FindQuery{}, fmt.Errorf("invalid regular expression: %w", err)
+			}
+			query.Regexp = re
+
+		default:
+			if query.Pattern != "" || query.Regexp != nil {
+				return FindQuery{}, fmt.Errorf("only one name pattern is allowed, got %q", field)
+			}
+			if _, err := path.Match(field, ""); err != nil {
+				return FindQuery{}, fmt.Errorf("invalid pattern %q: %w", field, err)
+			}
+			query.Pattern = field
+		}
+	}
+	return query, nil
+}
+
+// parseSize parses a size like 512, 10K or 1.5G
+func parseSize(value string) (int64, error) {
+	multiplier := 1.0
+	if value != "" {
+		switch strings.ToUpper(value[len(value)-1:]) {
+		case "K":
+			multiplier = 1 << 10
+		case "M":
+			multiplier = 1 << 20
+		case "G":
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			value = value[:len(value)-1]
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(number * multiplier), nil
+}
+
+// parseAge parses an age like 30m, 12h, 7d or 2w
+func parseAge(value string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		's': time.Second, 'm': time.Minute, 'h': time.Hour,
+		'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour,
+	}
+	if value == "" || units[value[len(value)-1]] == 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30m, 12h, 7d)", value)
+	}
+
+	number, err := strconv.ParseFloat(value[:len(value)-1], 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+	return time.Duration(number * float64(units[value[len(value)-1]])), nil
+}
+
+// Matches reports whether a file satisfies the query
+func (q FindQuery) Matches(info FileInfo, now time.Time) bool {
+	if q.Regexp != nil {
+		if !q.Regexp.MatchString(info.Name) {
+			return false
+		}
+	} else if q.Pattern != "" {
+		if matched, _ := path.Match(q.Pattern, info.Name); !matched {
+			return false
+		}
+	}
+
+	// Size filters only make sense for files
+	if (q.MinSize >= 0 || q.MaxSize >= 0) && info.IsDir {
+		return false
+	}
+	if q.MinSize >= 0 && info.Size < q.MinSize {
+		return false
+	}
+	if q.MaxSize >= 0 && info.Size > q.MaxSize {
+		return false
+	}
+
+	age := now.Sub(info.ModTime)
+	if q.NewerThan > 0 && age > q.NewerThan {
+		return false
+	}
+	if q.OlderThan > 0 && age < q.OlderThan {
+		return false
+	}
+	return true
+}
+
+// Find walks the remote tree below root, calling found for every match until
+// the walk completes or ctx is cancelled. Unreadable directories are skipped
+// and symlinks are not followed.
+func (c *Client) Find(ctx context.Context, root string, query FindQuery, found func(FindResult)) error {
+	now := time.Now()
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if walker.Err() != nil || walker.Path() == root {
+			continue
+		}
+
+		info := findFileInfo(walker.Stat())
+		if query.Matches(info, now) {
+			found(FindResult{Path: walker.Path(), Info: info})
+		}
+	}
+	return nil
+}
+
+// FindLocal walks the local tree below root like Find does remotely
+func FindLocal(ctx context.Context, root string, query FindQuery, found func(FindResult)) error {
+	now := time.Now()
+	return filepath.WalkDir(root, func(fullPath string, entry fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil || fullPath == root {
+			// Skip what we can't read rather than aborting the search
+			return nil
+		}
+
+		stat, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		info := findFileInfo(stat)
+		if query.Matches(info, now) {
+			found(FindResult{Path: fullPath, Info: info})
+		}
+		return nil
+	})
+}
+
+// findFileInfo converts the result of a directory walk
+func findFileInfo(stat os.FileInfo) FileInfo {
+	return FileInfo{
+		Name:    stat.Name(),
+		Size:    stat.Size(),
+		ModTime: stat.ModTime(),
+		IsDir:   stat.IsDir(),
+		Mode:    stat.Mode(),
+		IsLink:  stat.Mode()&os.ModeSymlink != 0,
+	}
+}
@@ -0,0 +1,106 @@
+package ssh
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseFindQuery(t *testing.T) {
+	query, err := ParseFindQuery("*.log size>10M mtime<7d")
+	if err != nil {
+		t.Fatalf("ParseFindQuery failed: %v", err)
+	}
+	if query.Pattern != "*.log" || query.MinSize != 10<<20+1 || query.NewerThan != 7*24*time.Hour {
+		t.Errorf("Unexpected query: %+v", query)
+	}
+
+	query, err = ParseFindQuery(`/^access\.log\.\d+$/ size<1.5K mtime>2w`)
+	if err != nil {
+		t.Fatalf("ParseFindQuery failed: %v", err)
+	}
+	if query.Regexp == nil || query.MaxSize != 1535 || query.OlderThan != 14*24*time.Hour {
+		t.Errorf("Unexpected query: %+v", query)
+	}
+
+	for _, input := range []string{"size>lots", "mtime<7y", "/[/", "*.go *.rs", "[a"} {
+		if _, err := ParseFindQuery(input); err == nil {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestFindQueryMatches(t *testing.T) {
+	now := time.Now()
+	query, _ := ParseFindQuery("*.log size>1K mtime<1d")
+
+	tests := []struct {
+		info     FileInfo
+		expected bool
+	}{
+		{FileInfo{Name: "app.log", Size: 4096, ModTime: now.Add(-time.Hour)}, true},
+		{FileInfo{Name: "app.txt", Size: 4096, ModTime: now.Add(-time.Hour)}, false},
+		{FileInfo{Name: "app.log", Size: 100, ModTime: now.Add(-time.Hour)}, false},
+		{FileInfo{Name: "app.log", Size: 4096, ModTime: now.Add(-48 * time.Hour)}, false},
+		{FileInfo{Name: "logs.log", IsDir: true, ModTime: now}, false},
+	}
+	for _, test := range tests {
+		if got := query.Matches(test.info, now); got != test.expected {
+			t.Errorf("Matches(%+v) = %v, expected %v", test.info, got, test.expected)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	client := newTestClient(t)
+	root := t.TempDir()
+	for _, name := range []string{"a.log", "sub/b.log", "sub/deeper/c.log", "sub/notes.txt"} {
+		target := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(target), 0755)
+		if err := os.WriteFile(target, []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	query, _ := ParseFindQuery("*.log")
+	expected := []string{"a.log", "sub/b.log", "sub/deeper/c.log"}
+
+	for name, find := range map[string]func(context.Context, string, FindQuery, func(FindResult)) error{
+		"remote": client.Find,
+		"local":  FindLocal,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var found []string
+			err := find(context.Background(), root, query, func(result FindResult) {
+				rel, _ := filepath.Rel(root, result.Path)
+				found = append(found, filepath.ToSlash(rel))
+			})
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			sort.Strings(found)
+			if len(found) != len(expected) {
+				t.Fatalf("Expected %v, got %v", expected, found)
+			}
+			for i := range expected {
+				if found[i] != expected[i] {
+					t.Errorf("Expected %v, got %v", expected, found)
+				}
+			}
+		})
+	}
+}
+
+func TestFindCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := FindLocal(ctx, t.TempDir(), FindQuery{MinSize: -1, MaxSize: -1}, func(FindResult) {
+		t.Errorf("Expected no results after cancellation")
+	})
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxGrepLine limits how much of a matching line is kept
+const maxGrepLine = 512
+
+// GrepQuery describes what a content search looks for
+type GrepQuery struct {
+	Pattern  string // Fixed string, or a Go regular expression when IsRegexp is set
+	IsRegexp bool
+	Regexp   *regexp.Regexp // Compiled Pattern, used when searching without grep
+	ERE      string         // Pattern as an extended regular expression for grep -E
+}
+
+// GrepMatch is a line of a file that matched a content search
+type GrepMatch struct {
+	Path string // Full path of the file
+	Line int    // 1-based line number
+	Text string
+}
+
+// ParseGrepQuery parses a content search, which is a fixed string or a
+// regular expression when written as /regexp/. Regular expressions that
+// grep -E can't run the same way, such as ones using \b, are rejected.
+func ParseGrepQuery(input string) (GrepQuery, error) {
+	if input == "" {
+		return GrepQuery{}, fmt.Errorf("search text cannot be empty")
+	}
+
+	if len(input) > 2 && strings.HasPrefix(input, "/") && strings.HasSuffix(input, "/") {
+		pattern := input[1 : len(input)-1]
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return GrepQuery{}, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		ere, err := toERE(pattern)
+		if err != nil {
+			return GrepQuery{}, fmt.Errorf("unsupported regular expression: %w", err)
+		}
+		return GrepQuery{Pattern: pattern, IsRegexp: true, Regexp: re, ERE: ere}, nil
+	}
+	return GrepQuery{Pattern: input, Regexp: regexp.MustCompile(regexp.QuoteMeta(input))}, nil
+}
+
+// Grep searches the contents of the files below root on the server, calling
+// found for every matching line until the search completes or ctx is
+// cancelled. It runs grep over an exec session when the server has one, and
+// otherwise reads every file over SFTP. Binary and unreadable files are skipped.
+func (c *Client) Grep(ctx context.Context, root string, query GrepQuery, found func(GrepMatch)) error {
+	if c.CanExec("grep") {
+		err := c.grepExec(ctx, root, query, found)
+		if !errors.Is(err, ErrNoShell) {
+			return err
+		}
+	}
+	return c.grepSFTP(ctx, root, query, found)
+}
+
+// grepExec runs grep -r on the server and parses its output as it arrives.
+// It returns ErrNoShell when grep failed without finding anything for a
+// reason other than the pattern, such as lacking --null, so the caller can
+// fall back to SFTP.
+func (c *Client) grepExec(ctx context.Context, root string, query GrepQuery, found func(GrepMatch)) error {
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open grep output: %w", err)
+	}
+
+	flags, pattern := "-rnsIF", query.Pattern
+	if query.IsRegexp {
+		flags, pattern = "-rnsIE", query.ERE
+	}
+	stderr := &cappedBuffer{limit: maxCommandOutput}
+	session.Stderr = stderr
+	command := fmt.Sprintf("grep %s --null -e %s -- %s", flags, ShellQuote(pattern), ShellQuote(root))
+	if err := session.Start(command); err != nil {
+		return fmt.Errorf("failed to start grep: %w", err)
+	}
+
+	// Closing the session stops grep when the search is cancelled
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	matched := false
+	reader := bufio.NewReader(stdout)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if match, ok := parseGrepLine(line); ok {
+			matched = true
+			found(match)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	err = session.Wait()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	status := 0
+	var exitErr *ssh.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		status = exitErr.ExitStatus()
+	case err != nil:
+		return fmt.Errorf("failed to run grep: %w", err)
+	}
+	return grepStatus(status, matched, strings.TrimSpace(stderr.String()))
+}
+
+// grepStatus interprets how grep exited. Status 1 means nothing matched, and
+// 2 means an error: with matches or without a message (-s silences unreadable
+// files) some files couldn't be read, a complaint about options means grep
+// lacks --null, and anything else is a bad pattern.
+func grepStatus(status int, matched bool, stderr string) error {
+	if status <= 1 || matched {
+		return nil
+	}
+	if status != 2 || strings.Contains(stderr, "option") || strings.Contains(stderr, "usage") {
+		return ErrNoShell
+	}
+	if stderr != "" {
+		return fmt.Errorf("grep failed: %s", stderr)
+	}
+	return nil
+}
+
+// parseGrepLine parses a line of grep --null -n output: the file name, a NUL
+// byte, the line number, a colon and the line itself
+func parseGrepLine(line []byte) (GrepMatch, bool) {
+	name, rest, ok := bytes.Cut(line, []byte{0})
+	if !ok {
+		return GrepMatch{}, false
+	}
+	number, text, ok := bytes.Cut(rest, []byte{':'})
+	if !ok {
+		return GrepMatch{}, false
+	}
+	lineNumber, err := strconv.Atoi(string(number))
+	if err != nil {
+		return GrepMatch{}, false
+	}
+	return GrepMatch{Path: path.Clean(string(name)), Line: lineNumber, Text: grepText(text)}, true
+}
+
+// grepText trims a matching line for display
+func grepText(line []byte) string {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) > maxGrepLine {
+		line = line[:maxGrepLine]
+	}
+	return string(line)
+}
+
+// grepSFTP searches every regular file below root by reading it over SFTP
+func (c *Client) grepSFTP(ctx context.Context, root string, query GrepQuery, found func(GrepMatch)) error {
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if walker.Err() != nil || !walker.Stat().Mode().IsRegular() {
+			continue
+		}
+
+		file, err := c.sftpClient.Open(walker.Path())
+		if err != nil {
+			continue
+		}
+		// A file that fails part way still contributes the matches read so far
+		grepReader(ctx, file, walker.Path(), query.Regexp, found)
+		file.Close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// GrepLocal searches the contents of the local files below root like Grep
+// does without a remote shell
+func GrepLocal(ctx context.Context, root string, query GrepQuery, found func(GrepMatch)) error {
+	return filepath.WalkDir(root, func(fullPath string, entry fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(fullPath)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+		grepReader(ctx, file, fullPath, query.Regexp, found)
+		return ctx.Err()
+	})
+}
+
+// grepReader reports the lines of r that match re. Files that look binary
+// are skipped, as grep -I does.
+func grepReader(ctx context.Context, r io.Reader, filePath string, re *regexp.Regexp, found func(GrepMatch)) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	head, _ := reader.Peek(8192)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+
+	for lineNumber := 1; ; lineNumber++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && re.Match(bytes.TrimSuffix(line, []byte("\n"))) {
+			found(GrepMatch{Path: filePath, Line: lineNumber, Text: grepText(line)})
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseGrepQuery(t *testing.T) {
+	query, err := ParseGrepQuery("db.example.com")
+	if err != nil {
+		t.Fatalf("ParseGrepQuery failed: %v", err)
+	}
+	if query.IsRegexp || !query.Regexp.MatchString("host = db.example.com") || query.Regexp.MatchString("host = dbxexample.com") {
+		t.Errorf("Fixed string search should match literally: %+v", query)
+	}
+
+	query, err = ParseGrepQuery(`/port\s*=\s*[0-9]+/`)
+	if err != nil {
+		t.Fatalf("ParseGrepQuery failed: %v", err)
+	}
+	if !query.IsRegexp || query.Pattern != `port\s*=\s*[0-9]+` || !query.Regexp.MatchString("port = 22") {
+		t.Errorf("Unexpected query: %+v", query)
+	}
+
+	for _, input := range []string{"", "/[/"} {
+		if _, err := ParseGrepQuery(input); err == nil {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestParseGrepLine(t *testing.T) {
+	match, ok := parseGrepLine([]byte("/etc//app.conf\x0012:host: db:5432\n"))
+	if !ok {
+		t.Fatal("Expected line to parse")
+	}
+	if match.Path != "/etc/app.conf" || match.Line != 12 || match.Text != "host: db:5432" {
+		t.Errorf("Unexpected match: %+v", match)
+	}
+
+	for _, line := range []string{"", "no separator:1:text", "/file\x00notanumber:text"} {
+		if _, ok := parseGrepLine([]byte(line)); ok {
+			t.Errorf("Expected %q to be rejected", line)
+		}
+	}
+}
+
+// writeGrepTree creates a tree of files with a few matching lines
+func writeGrepTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"app.conf":          "name = app\nhost = db.example.com\n",
+		"sub/other.conf":    "host = cache.example.com\n\n# db.example.com is the primary\n",
+		"sub/binary.dat":    "db.example.com\x00\x01",
+		"sub/unrelated.txt": "nothing here\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestGrep(t *testing.T) {
+	client := newTestClient(t)
+	root := writeGrepTree(t)
+	query, _ := ParseGrepQuery("db.example.com")
+
+	searches := map[string]func(found func(GrepMatch)) error{
+		"remote": func(found func(GrepMatch)) error {
+			return client.grepSFTP(context.Background(), root, query, found)
+		},
+		"local": func(found func(GrepMatch)) error {
+			return GrepLocal(context.Background(), root, query, found)
+		},
+	}
+	for name, search := range searches {
+		var matches []string
+		err := search(func(match GrepMatch) {
+			rel, _ := filepath.Rel(root, match.Path)
+			matches = append(matches, fmt.Sprintf("%s:%d:%s", filepath.ToSlash(rel), match.Line, match.Text))
+		})
+		if err != nil {
+			t.Fatalf("%s: search failed: %v", name, err)
+		}
+
+		sort.Strings(matches)
+		expected := []string{"app.conf:2:host = db.example.com", "sub/other.conf:3:# db.example.com is the primary"}
+		if strings.Join(matches, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("%s: expected %q, got %q", name, expected, matches)
+		}
+	}
+}
+
+func TestGrepCancelled(t *testing.T) {
+	root := writeGrepTree(t)
+	query, _ := ParseGrepQuery("example")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	count := 0
+	err := GrepLocal(ctx, root, query, func(GrepMatch) {
+		count++
+		cancel()
+	})
+	if err != context.Canceled || count != 1 {
+		t.Errorf("Expected the search to stop after one match, got %d matches and error %v", count, err)
+	}
+}
+
+func TestReadFromLine(t *testing.T) {
+	content := "one\n" + strings.Repeat("x", 10000) + "\nthree\nfour\n"
+
+	tests := []struct {
+		line      int
+		limit     int64
+		expected  string
+		truncated bool
+	}{
+		{1, 3, "one", true},
+		{3, 100, "three\nfour\n", false},
+		{4, 4, "four", true},
+		{9, 100, "", false},
+	}
+	for _, test := range tests {
+		data, truncated, err := readFromLine(strings.NewReader(content), test.line, test.limit)
+		if err != nil {
+			t.Fatalf("readFromLine(%d) failed: %v", test.line, err)
+		}
+		if string(data) != test.expected || truncated != test.truncated {
+			t.Errorf("readFromLine(%d) = %q, %v; expected %q, %v", test.line, data, truncated, test.expected, test.truncated)
+		}
+	}
+}
+
+func TestToERE(t *testing.T) {
+	tests := map[string]string{
+		`port\s*=\s*[0-9]+`: "port[\t\f\r ]*=[\t\f\r ]*[0-9]+",
+		`\d{2,4}x{3}y{1,}`:  `[0-9]{2,4}x{3}y{1,}`,
+		`(?i)get|post`:      `[Gg][Ee][Tt]|[Pp][Oo][Ss][Tt]`,
+		`a.*?b`:             `a.*b`,
+		`(?:ab)+c`:          `(ab)+c`,
+		`x(?:a|b|)`:         `x([ab])?`,
+		`[^a]`:              `[^a]`,
+		`[]^\-[a]`:          `[]a[^-]`,
+		`[-^]`:              `[-^]`,
+		`[\^]`:              `\^`,
+		`a\.b\(c\)\{`:       `a\.b\(c\)\{`,
+		`^(error|warn):$`:   `^(error|warn):$`,
+	}
+	for pattern, expected := range tests {
+		ere, err := toERE(pattern)
+		if err != nil {
+			t.Errorf("toERE(%q) failed: %v", pattern, err)
+		} else if ere != expected {
+			t.Errorf("toERE(%q) = %q, expected %q", pattern, ere, expected)
+		}
+	}
+
+	for _, pattern := range []string{`\bword\b`, `\Bx`, `a\nb`, `\pL`, `[éè]`, `[^\x00-\x{10FFFF}]`} {
+		if ere, err := toERE(pattern); err == nil {
+			t.Errorf("Expected %q to be rejected, got %q", pattern, ere)
+		}
+	}
+}
+
+func TestToEREMatchesLikeGrep(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep isn't installed")
+	}
+
+	lines := []string{"port = 22", "PORT=8080", "get /index", "a]b", "a^b", "a-b", "x", "xab", "tab\there", "a.b", "ERROR:", "error: disk"}
+	file := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pattern := range []string{`port\s*=\s*\d+`, `(?i)port`, `[]^-]`, `^x(?:a|b|)$`, `\t`, `a\.b`, `^(?i:error):$`, `[^a-z ]`} {
+		query, err := ParseGrepQuery("/" + pattern + "/")
+		if err != nil {
+			t.Fatalf("ParseGrepQuery(%q) failed: %v", pattern, err)
+		}
+
+		output, _ := exec.Command("grep", "-E", "-e", query.ERE, file).Output()
+		var expected []string
+		for _, line := range lines {
+			if query.Regexp.MatchString(line) {
+				expected = append(expected, line)
+			}
+		}
+		got := strings.TrimSuffix(string(output), "\n")
+		if got != strings.Join(expected, "\n") {
+			t.Errorf("grep -E %q matched %q, Go matched %q", query.ERE, got, expected)
+		}
+	}
+}
+
+func TestGrepStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		matched  bool
+		stderr   string
+		expected string
+	}{
+		{0, true, "", ""},
+		{1, false, "", ""},
+		{2, true, "grep: a: Permission denied", ""},
+		{2, false, "", ""},
+		{2, false, "grep: unrecognized option '--null'", "no shell"},
+		{2, false, "usage: grep [-abcDEFGHhIiJLlmnOoqRSsUVvwxZ]", "no shell"},
+		{127, false, "sh: grep: not found", "no shell"},
+		{2, false, "grep: Unmatched ( or \\(", "grep failed: grep: Unmatched ( or \\("},
+	}
+	for _, test := range tests {
+		err := grepStatus(test.status, test.matched, test.stderr)
+		switch {
+		case test.expected == "" && err != nil:
+			t.Errorf("grepStatus(%d, %q) = %v, expected no error", test.status, test.stderr, err)
+		case test.expected == "no shell" && !errors.Is(err, ErrNoShell):
+			t.Errorf("grepStatus(%d, %q) = %v, expected ErrNoShell", test.status, test.stderr, err)
+		case test.expected != "" && test.expected != "no shell" && (err == nil || err.Error() != test.expected):
+			t.Errorf("grepStatus(%d, %q) = %v, expected %q", test.status, test.stderr, err, test.expected)
+		}
+	}
+}
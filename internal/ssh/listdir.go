@@ -0,0 +1,190 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// findListFormat makes find print one entry as NUL terminated fields: type,
+// type of what a link points to, permissions in octal, size, modification
+// time, uid, gid, link target and name
+const findListFormat = `%y\0%Y\0%m\0%s\0%T@\0%U\0%G\0%l\0%f\0`
+
+// findListFields is how many fields findListFormat prints per entry
+const findListFields = 9
+
+// canListExec reports whether the server can list directories with GNU
+// find -printf, checking once per connection
+func (c *Client) canListExec() bool {
+	c.listExecOnce.Do(func() {
+		if c.sshClient == nil {
+			return
+		}
+		result, err := c.RunCommand("/", "find / -maxdepth 0 -printf ok")
+		c.listExec = err == nil && result.ExitStatus == 0 && string(result.Stdout) == "ok"
+	})
+	return c.listExec
+}
+
+// listDirExec lists a remote directory with find over an exec session,
+// handing entries to page as find prints them. listed reports whether any
+// entries were handed over.
+func (c *Client) listDirExec(ctx context.Context, dir string, page func([]FileInfo)) (listed bool, err error) {
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return false, fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return false, fmt.Errorf("failed to open listing: %w", err)
+	}
+	stderr := &cappedBuffer{limit: maxCommandOutput}
+	session.Stderr = stderr
+	// -H lists the directory a symlink points to rather than the link itself
+	command := fmt.Sprintf("find -H %s -mindepth 1 -maxdepth 1 -printf %s", ShellQuote(dir), ShellQuote(findListFormat))
+	if err := session.Start(command); err != nil {
+		return false, fmt.Errorf("failed to start find: %w", err)
+	}
+
+	// Closing the session stops find when the listing is cancelled
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	count, readErr := readFindListing(stdout, c.remoteIDNames(), page)
+	err = session.Wait()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return count > 0, ctxErr
+	}
+	if readErr != nil {
+		return count > 0, fmt.Errorf("failed to list directory: %w", readErr)
+	}
+
+	var exitErr *ssh.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		return count > 0, fmt.Errorf("failed to list directory: %s", strings.TrimSpace(stderr.String()))
+	case err != nil:
+		return count > 0, fmt.Errorf("failed to run find: %w", err)
+	}
+	return count > 0, nil
+}
+
+// readFindListing parses the output of find -printf findListFormat, handing
+// the entries to page in batches of up to ListPageSize as soon as each batch
+// is complete. It returns how many entries were handed over.
+func readFindListing(r io.Reader, names *idNames, page func([]FileInfo)) (int, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var batch []FileInfo
+	count := 0
+	fields := make([]string, findListFields)
+	for {
+		for i := range fields {
+			field, err := reader.ReadString(0)
+			if err == io.EOF && i == 0 && field == "" {
+				if len(batch) > 0 {
+					page(batch)
+				}
+				return count + len(batch), nil
+			}
+			if err != nil {
+				return count, fmt.Errorf("truncated listing: %w", err)
+			}
+			fields[i] = strings.TrimSuffix(field, "\x00")
+		}
+
+		file, err := parseFindEntry(fields, names)
+		if err != nil {
+			return count, err
+		}
+		batch = append(batch, file)
+		if len(batch) == ListPageSize {
+			page(batch)
+			count += len(batch)
+			batch = nil
+		}
+	}
+}
+
+// parseFindEntry converts the fields find printed for one entry
+func parseFindEntry(fields []string, names *idNames) (FileInfo, error) {
+	perm, permErr := strconv.ParseUint(fields[2], 8, 32)
+	size, sizeErr := strconv.ParseInt(fields[3], 10, 64)
+	modTime, timeErr := parseFindTime(fields[4])
+	uid, uidErr := strconv.Atoi(fields[5])
+	gid, gidErr := strconv.Atoi(fields[6])
+	if err := errors.Join(permErr, sizeErr, timeErr, uidErr, gidErr); err != nil {
+		return FileInfo{}, fmt.Errorf("unexpected listing of %s: %w", fields[8], err)
+	}
+
+	mode := findFileType(fields[0]) | os.FileMode(perm).Perm()
+	for bit, special := range map[uint64]os.FileMode{04000: os.ModeSetuid, 02000: os.ModeSetgid, 01000: os.ModeSticky} {
+		if perm&bit != 0 {
+			mode |= special
+		}
+	}
+
+	file := FileInfo{
+		Name:    fields[8],
+		Size:    size,
+		ModTime: modTime,
+		IsDir:   mode.IsDir(),
+		Mode:    mode,
+		UID:     uid,
+		GID:     gid,
+		Owner:   nameOrID(names.users, uid),
+		Group:   nameOrID(names.groups, gid),
+		IsLink:  mode&os.ModeSymlink != 0,
+	}
+	if file.IsLink {
+		file.LinkTarget = fields[7]
+		// Links to directories can be entered like directories
+		file.IsDir = fields[1] == "d"
+	}
+	return file, nil
+}
+
+// findFileType converts a file type letter printed by find's %y
+func findFileType(letter string) os.FileMode {
+	switch letter {
+	case "d":
+		return os.ModeDir
+	case "l":
+		return os.ModeSymlink
+	case "p":
+		return os.ModeNamedPipe
+	case "s":
+		return os.ModeSocket
+	case "c":
+		return os.ModeDevice | os.ModeCharDevice
+	case "b":
+		return os.ModeDevice
+	}
+	return 0
+}
+
+// parseFindTime parses a time printed by find's %T@, seconds since the epoch
+// with a fraction
+func parseFindTime(value string) (time.Time, error) {
+	seconds, fraction, _ := strings.Cut(value, ".")
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	fraction = (fraction + "000000000")[:9]
+	nsec, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, nsec), nil
+}
@@ -0,0 +1,107 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// findRecord prints one entry the way find -printf findListFormat does
+func findRecord(name string) string {
+	return fmt.Sprintf("f\x00f\x00644\x0012\x001700000000.5\x001000\x001000\x00\x00%s\x00", name)
+}
+
+func TestReadFindListingPagesEarly(t *testing.T) {
+	r, w := io.Pipe()
+	pages := make(chan []FileInfo)
+	done := make(chan error, 1)
+	go func() {
+		_, err := readFindListing(r, &idNames{users: map[int]string{1000: "deploy"}}, func(page []FileInfo) { pages <- page })
+		done <- err
+	}()
+
+	go func() {
+		for i := range ListPageSize {
+			io.WriteString(w, findRecord(fmt.Sprintf("file%04d", i)))
+		}
+	}()
+	// The first page arrives while find is still running
+	select {
+	case page := <-pages:
+		if len(page) != ListPageSize || page[0].Name != "file0000" || page[0].Owner != "deploy" || page[0].Group != "1000" {
+			t.Fatalf("Unexpected first page: %d entries starting with %+v", len(page), page[0])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a full page before the listing ended")
+	}
+
+	go func() {
+		io.WriteString(w, findRecord("last"))
+		w.Close()
+	}()
+	if page := <-pages; len(page) != 1 || page[0].Name != "last" {
+		t.Errorf("Expected the rest in a last page, got %+v", page)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("readFindListing failed: %v", err)
+	}
+}
+
+func TestReadFindListingTruncated(t *testing.T) {
+	r, w := io.Pipe()
+	go func() {
+		io.WriteString(w, "f\x00f\x00644\x00")
+		w.Close()
+	}()
+	if _, err := readFindListing(r, &idNames{}, func([]FileInfo) {}); err == nil {
+		t.Error("Expected a truncated entry to be reported")
+	}
+}
+
+func TestFindListingMatchesLocal(t *testing.T) {
+	if _, err := exec.LookPath("find"); err != nil {
+		t.Skip("find isn't installed")
+	}
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"docs/readme.md": "# docs\n", "notes.txt": "hello"})
+	if err := os.Chmod(filepath.Join(dir, "notes.txt"), 0640|os.ModeSetgid); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"current": "docs", "latest": "notes.txt", "broken": "missing"} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	output, err := exec.Command("find", "-H", dir, "-mindepth", "1", "-maxdepth", "1", "-printf", findListFormat).Output()
+	if err != nil {
+		t.Skipf("find -printf isn't supported: %v", err)
+	}
+	var listed []FileInfo
+	if _, err := readFindListing(bytes.NewReader(output), &idNames{}, func(page []FileInfo) { listed = append(listed, page...) }); err != nil {
+		t.Fatalf("readFindListing failed: %v", err)
+	}
+	expected, err := ListLocalDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+	sort.Slice(expected, func(i, j int) bool { return expected[i].Name < expected[j].Name })
+	if len(listed) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(listed))
+	}
+	for i, want := range expected {
+		got := listed[i]
+		if got.Name != want.Name || got.Size != want.Size || got.IsDir != want.IsDir || got.Mode != want.Mode ||
+			got.IsLink != want.IsLink || got.LinkTarget != want.LinkTarget || got.UID != want.UID || !got.ModTime.Equal(want.ModTime) {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package ssh
+
+import (
+	"bufio"
+	"io"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// idNames maps numeric user and group ids to names
+type idNames struct {
+	users  map[int]string
+	groups map[int]string
+}
+
+// parseIDNames parses /etc/passwd or /etc/group style content (name:x:id:...)
+// into a map from id to name
+func parseIDNames(r io.Reader) map[int]string {
+	names := make(map[int]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		if _, exists := names[id]; !exists {
+			names[id] = fields[0]
+		}
+	}
+
+	return names
+}
+
+// remoteIDNames returns the remote user and group names, reading
+// /etc/passwd and /etc/group once per connection. Servers that don't expose
+// them (e.g. chrooted SFTP accounts) simply yield empty maps.
+func (c *Client) remoteIDNames() *idNames {
+	c.idNamesOnce.Do(func() {
+		c.idNames = &idNames{
+			users:  c.readRemoteIDFile("/etc/passwd"),
+			groups: c.readRemoteIDFile("/etc/group"),
+		}
+	})
+	return c.idNames
+}
+
+// readRemoteIDFile reads and parses a remote passwd/group file
+func (c *Client) readRemoteIDFile(remotePath string) map[int]string {
+	file, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return map[int]string{}
+	}
+	defer file.Close()
+
+	return parseIDNames(file)
+}
+
+// nameOrID returns the name for an id, or the id itself when it is unknown
+func nameOrID(names map[int]string, id int) string {
+	if id < 0 {
+		return ""
+	}
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return strconv.Itoa(id)
+}
+
+// localIDNames caches local user and group lookups
+var localIDNames = struct {
+	sync.Mutex
+	idNames
+}{idNames: idNames{users: map[int]string{}, groups: map[int]string{}}}
+
+// localUserName returns the local user name for a uid
+func localUserName(uid int) string {
+	return lookupLocalName(localIDNames.users, uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+// localGroupName returns the local group name for a gid
+func localGroupName(gid int) string {
+	return lookupLocalName(localIDNames.groups, gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+// lookupLocalName resolves an id through lookup, caching the result
+func lookupLocalName(cache map[int]string, id int, lookup func(id string) (string, error)) string {
+	if id < 0 {
+		return ""
+	}
+
+	localIDNames.Lock()
+	defer localIDNames.Unlock()
+
+	if name, ok := cache[id]; ok {
+		return name
+	}
+	name, err := lookup(strconv.Itoa(id))
+	if err != nil {
+		name = strconv.Itoa(id)
+	}
+	cache[id] = name
+	return name
+}
@@ -0,0 +1,36 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseIDNames(t *testing.T) {
+	content := `
+# comment
+root:x:0:0:root:/root:/bin/bash
+deploy:x:1001:1001::/home/deploy:/bin/sh
+broken line
+nobody:x:notanumber:0::/:/bin/false
+alias:x:1001:1001::/home/alias:/bin/sh
+`
+
+	names := parseIDNames(strings.NewReader(content))
+
+	if len(names) != 2 {
+		t.Errorf("Expected 2 names, got %d", len(names))
+	}
+	if names[0] != "root" {
+		t.Errorf("Expected uid 0 to be 'root', got '%s'", names[0])
+	}
+	if names[1001] != "deploy" {
+		t.Errorf("Expected the first entry for uid 1001 to win, got '%s'", names[1001])
+	}
+
+	if got := nameOrID(names, 42); got != "42" {
+		t.Errorf("Expected unknown id to fall back to '42', got '%s'", got)
+	}
+	if got := nameOrID(names, -1); got != "" {
+		t.Errorf("Expected missing id to be empty, got '%s'", got)
+	}
+}
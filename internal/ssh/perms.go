@@ -0,0 +1,222 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/sftp"
+)
+
+// specialModeBits are preserved when permissions are rewritten
+const specialModeBits = os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// Permissions describes the mode and ownership of a file
+type Permissions struct {
+	Mode  os.FileMode
+	UID   int
+	GID   int
+	IsDir bool
+}
+
+// PermissionChange describes a chmod/chown to apply to a file or tree
+type PermissionChange struct {
+	FileMode  os.FileMode // applied to files, and to the target itself when not recursive
+	DirMode   os.FileMode // applied to directories when recursive
+	UID       int         // -1 leaves the owner unchanged
+	GID       int         // -1 leaves the group unchanged
+	Recursive bool
+}
+
+// modeFor returns the permission bits to apply to an entry, keeping its special bits
+func (pc PermissionChange) modeFor(current os.FileMode, isDir bool) os.FileMode {
+	mode := pc.FileMode
+	if isDir && pc.Recursive {
+		mode = pc.DirMode
+	}
+	return mode.Perm() | current&specialModeBits
+}
+
+// owner returns the uid/gid to apply given the current owner, and whether chown is needed
+func (pc PermissionChange) owner(uid, gid int) (int, int, bool) {
+	if pc.UID < 0 && pc.GID < 0 {
+		return uid, gid, false
+	}
+	if pc.UID >= 0 {
+		uid = pc.UID
+	}
+	if pc.GID >= 0 {
+		gid = pc.GID
+	}
+	return uid, gid, true
+}
+
+// GetPermissions returns the mode and ownership of a remote file
+func (c *Client) GetPermissions(remotePath string) (Permissions, error) {
+	info, err := c.sftpClient.Stat(remotePath)
+	if err != nil {
+		return Permissions{}, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	return remotePermissions(info), nil
+}
+
+// remotePermissions extracts permissions from an SFTP file info
+func remotePermissions(info os.FileInfo) Permissions {
+	perms := Permissions{
+		Mode:  info.Mode() & (os.ModePerm | specialModeBits),
+		UID:   -1,
+		GID:   -1,
+		IsDir: info.IsDir(),
+	}
+	if stat, ok := info.Sys().(*sftp.FileStat); ok {
+		perms.UID = int(stat.UID)
+		perms.GID = int(stat.GID)
+	}
+	return perms
+}
+
+// Chmod changes the mode of a remote file
+func (c *Client) Chmod(remotePath string, mode os.FileMode) error {
+	if err := c.sftpClient.Chmod(remotePath, mode); err != nil {
+		return fmt.Errorf("failed to chmod %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// Chown changes the owner and group of a remote file
+func (c *Client) Chown(remotePath string, uid, gid int) error {
+	if err := c.sftpClient.Chown(remotePath, uid, gid); err != nil {
+		return fmt.Errorf("failed to chown %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// ApplyPermissions applies a permission change to a remote path, descending
+// into directories when the change is recursive. Symlinks inside the tree are
+// left alone since changing them would affect their targets.
+func (c *Client) ApplyPermissions(remotePath string, change PermissionChange) error {
+	return c.applyPermissions(remotePath, change, true)
+}
+
+func (c *Client) applyPermissions(remotePath string, change PermissionChange, isRoot bool) error {
+	info, err := c.sftpClient.Lstat(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !isRoot {
+			return nil
+		}
+		if info, err = c.sftpClient.Stat(remotePath); err != nil {
+			return fmt.Errorf("failed to stat %s: %w", remotePath, err)
+		}
+	}
+
+	perms := remotePermissions(info)
+	if err := c.Chmod(remotePath, change.modeFor(info.Mode(), perms.IsDir)); err != nil {
+		return err
+	}
+	if uid, gid, ok := change.owner(perms.UID, perms.GID); ok {
+		if err := c.Chown(remotePath, uid, gid); err != nil {
+			return err
+		}
+	}
+
+	if !perms.IsDir || !change.Recursive {
+		return nil
+	}
+
+	entries, err := c.sftpClient.ReadDir(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to list directory %s: %w", remotePath, err)
+	}
+	for _, entry := range entries {
+		if err := c.applyPermissions(path.Join(remotePath, entry.Name()), change, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLocalPermissions returns the mode and ownership of a local file
+func GetLocalPermissions(localPath string) (Permissions, error) {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return Permissions{}, fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
+	return localPermissions(info), nil
+}
+
+// localPermissions extracts permissions from a local file info
+func localPermissions(info os.FileInfo) Permissions {
+	uid, gid := localOwner(info)
+	return Permissions{
+		Mode:  info.Mode() & (os.ModePerm | specialModeBits),
+		UID:   uid,
+		GID:   gid,
+		IsDir: info.IsDir(),
+	}
+}
+
+// ChmodLocal changes the mode of a local file
+func ChmodLocal(localPath string, mode os.FileMode) error {
+	if err := os.Chmod(localPath, mode); err != nil {
+		return fmt.Errorf("failed to chmod %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// ChownLocal changes the owner and group of a local file
+func ChownLocal(localPath string, uid, gid int) error {
+	if err := os.Chown(localPath, uid, gid); err != nil {
+		return fmt.Errorf("failed to chown %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// ApplyLocalPermissions applies a permission change to a local path, descending
+// into directories when the change is recursive
+func ApplyLocalPermissions(localPath string, change PermissionChange) error {
+	return applyLocalPermissions(localPath, change, true)
+}
+
+func applyLocalPermissions(localPath string, change PermissionChange, isRoot bool) error {
+	info, err := os.Lstat(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !isRoot {
+			return nil
+		}
+		if info, err = os.Stat(localPath); err != nil {
+			return fmt.Errorf("failed to stat %s: %w", localPath, err)
+		}
+	}
+
+	perms := localPermissions(info)
+	if err := ChmodLocal(localPath, change.modeFor(info.Mode(), perms.IsDir)); err != nil {
+		return err
+	}
+	if uid, gid, ok := change.owner(perms.UID, perms.GID); ok {
+		if err := ChownLocal(localPath, uid, gid); err != nil {
+			return err
+		}
+	}
+
+	if !perms.IsDir || !change.Recursive {
+		return nil
+	}
+
+	entries, err := os.ReadDir(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to list directory %s: %w", localPath, err)
+	}
+	for _, entry := range entries {
+		if err := applyLocalPermissions(filepath.Join(localPath, entry.Name()), change, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestApplyLocalPermissionsRecursive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions are not supported on Windows")
+	}
+
+	tempDir := t.TempDir()
+	root := filepath.Join(tempDir, "root")
+	sub := filepath.Join(root, "sub")
+	if err := os.MkdirAll(sub, 0700); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	file := filepath.Join(sub, "script.sh")
+	if err := os.WriteFile(file, []byte("#!/bin/sh\n"), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	change := PermissionChange{
+		FileMode:  0644,
+		DirMode:   0755,
+		UID:       -1,
+		GID:       -1,
+		Recursive: true,
+	}
+	if err := ApplyLocalPermissions(root, change); err != nil {
+		t.Fatalf("ApplyLocalPermissions failed: %v", err)
+	}
+
+	for path, want := range map[string]os.FileMode{root: 0755, sub: 0755, file: 0644} {
+		perms, err := GetLocalPermissions(path)
+		if err != nil {
+			t.Fatalf("GetLocalPermissions failed: %v", err)
+		}
+		if perms.Mode.Perm() != want {
+			t.Errorf("Expected mode %o for %s, got %o", want, path, perms.Mode.Perm())
+		}
+	}
+
+	// Without recursion only the target changes, using the file mode
+	change = PermissionChange{FileMode: 0700, UID: -1, GID: -1}
+	if err := ApplyLocalPermissions(root, change); err != nil {
+		t.Fatalf("ApplyLocalPermissions failed: %v", err)
+	}
+	if perms, _ := GetLocalPermissions(root); perms.Mode.Perm() != 0700 {
+		t.Errorf("Expected mode 700 for root, got %o", perms.Mode.Perm())
+	}
+	if perms, _ := GetLocalPermissions(sub); perms.Mode.Perm() != 0755 {
+		t.Errorf("Expected sub directory to keep mode 755, got %o", perms.Mode.Perm())
+	}
+}
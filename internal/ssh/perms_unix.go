@@ -0,0 +1,16 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"os"
+	"syscall"
+)
+
+// localOwner returns the uid and gid of a local file
+func localOwner(info os.FileInfo) (int, int) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(stat.Uid), int(stat.Gid)
+	}
+	return -1, -1
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package ssh
+
+import "os"
+
+// localOwner returns -1 for both ids since Windows has no POSIX ownership
+func localOwner(info os.FileInfo) (int, int) {
+	return -1, -1
+}
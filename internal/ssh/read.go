@@ -0,0 +1,87 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ReadFileHead reads up to limit bytes from the start of a remote file and
+// reports whether the file continues beyond them
+func (c *Client) ReadFileHead(remotePath string, limit int64) ([]byte, bool, error) {
+	file, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	defer file.Close()
+
+	return readHead(file, limit)
+}
+
+// ReadLocalFileHead reads up to limit bytes from the start of a local file and
+// reports whether the file continues beyond them
+func ReadLocalFileHead(localPath string, limit int64) ([]byte, bool, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	return readHead(file, limit)
+}
+
+// ReadFileFromLine reads up to limit bytes of a remote file starting at a
+// 1-based line number and reports whether the file continues beyond them
+func (c *Client) ReadFileFromLine(remotePath string, line int, limit int64) ([]byte, bool, error) {
+	file, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	defer file.Close()
+
+	return readFromLine(file, line, limit)
+}
+
+// ReadLocalFileFromLine reads up to limit bytes of a local file starting at a
+// 1-based line number and reports whether the file continues beyond them
+func ReadLocalFileFromLine(localPath string, line int, limit int64) ([]byte, bool, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	return readFromLine(file, line, limit)
+}
+
+// readFromLine skips the lines before line and reads from there
+func readFromLine(r io.Reader, line int, limit int64) ([]byte, bool, error) {
+	reader := bufio.NewReader(r)
+	for skipped := 1; skipped < line; {
+		_, err := reader.ReadSlice('\n')
+		switch err {
+		case nil:
+			skipped++
+		case bufio.ErrBufferFull:
+			// Still inside a long line
+		case io.EOF:
+			return nil, false, nil
+		default:
+			return nil, false, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+	return readHead(reader, limit)
+}
+
+// readHead reads one byte past limit to find out whether the data was truncated
+func readHead(r io.Reader, limit int64) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return data[:limit], true, nil
+	}
+	return data, false, nil
+}
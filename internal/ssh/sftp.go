@@ -1,9 +1,11 @@
 package ssh
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/pkg/sftp"
@@ -12,17 +14,28 @@ import (
 
 // FileInfo represents a file or directory
 type FileInfo struct {
-	Name    string
-	Size    int64
-	ModTime time.Time
-	IsDir   bool
+	Name       string
+	Size       int64
+	ModTime    time.Time
+	IsDir      bool
+	Mode       os.FileMode
+	UID        int
+	GID        int
+	Owner      string // Owner name, or the uid when it can't be resolved
+	Group      string // Group name, or the gid when it can't be resolved
+	IsLink     bool
+	LinkTarget string
 }
 
 // Client wraps SSH and SFTP clients
 type Client struct {
-	sshClient  *ssh.Client
-	sftpClient *sftp.Client
-	host       SSHHost
+	sshClient    *ssh.Client
+	sftpClient   *sftp.Client
+	host         SSHHost
+	idNames      *idNames
+	idNamesOnce  sync.Once
+	listExec     bool // The server lists directories with find -printf
+	listExecOnce sync.Once
 }
 
 // NewClient creates a new SSH/SFTP client
@@ -108,24 +121,111 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// HomeDir returns the directory the SFTP session starts in, normally the
+// user's home or the root of a chroot, falling back to "/"
+func (c *Client) HomeDir() string {
+	if dir, err := c.sftpClient.Getwd(); err == nil && dir != "" {
+		return dir
+	}
+	if dir, err := c.sftpClient.RealPath("."); err == nil && dir != "" {
+		return dir
+	}
+	return "/"
+}
+
+// ListPageSize is how many entries ListDirPages hands over at a time
+const ListPageSize = 500
+
 // ListDir lists files in a remote directory
 func (c *Client) ListDir(path string) ([]FileInfo, error) {
-	files, err := c.sftpClient.ReadDir(path)
+	var result []FileInfo
+	err := c.ListDirPages(context.Background(), path, func(page []FileInfo) {
+		result = append(result, page...)
+	})
+	return result, err
+}
+
+// ListDirPages lists a remote directory, handing its entries to page in
+// batches of up to ListPageSize as they arrive, so the first entries of a
+// huge directory show up long before the last. Servers with GNU find stream
+// the listing over an exec session; others are listed over SFTP, where the
+// directory is read in one go and only resolving symlinks is spread over
+// the pages. Cancelling ctx stops the listing.
+func (c *Client) ListDirPages(ctx context.Context, path string, page func([]FileInfo)) error {
+	if c.canListExec() {
+		listed, err := c.listDirExec(ctx, path, page)
+		if listed || err == nil || ctx.Err() != nil {
+			return err
+		}
+		// Nothing was listed, let SFTP report why the usual way
+	}
+	return c.listDirSFTP(ctx, path, page)
+}
+
+// listDirSFTP lists a remote directory over SFTP for ListDirPages
+func (c *Client) listDirSFTP(ctx context.Context, path string, page func([]FileInfo)) error {
+	files, err := c.sftpClient.ReadDirContext(ctx, path)
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to list directory: %w", err)
+		return fmt.Errorf("failed to list directory: %w", err)
 	}
 
-	result := make([]FileInfo, len(files))
-	for i, file := range files {
-		result[i] = FileInfo{
-			Name:    file.Name(),
-			Size:    file.Size(),
-			ModTime: file.ModTime(),
-			IsDir:   file.IsDir(),
+	names := c.remoteIDNames()
+
+	for start := 0; start < len(files); start += ListPageSize {
+		batch := files[start:min(start+ListPageSize, len(files))]
+		result := make([]FileInfo, len(batch))
+		for i, file := range batch {
+			perms := remotePermissions(file)
+			result[i] = FileInfo{
+				Name:    file.Name(),
+				Size:    file.Size(),
+				ModTime: file.ModTime(),
+				IsDir:   file.IsDir(),
+				Mode:    file.Mode(),
+				UID:     perms.UID,
+				GID:     perms.GID,
+				Owner:   nameOrID(names.users, perms.UID),
+				Group:   nameOrID(names.groups, perms.GID),
+				IsLink:  file.Mode()&os.ModeSymlink != 0,
+			}
+			if result[i].IsLink {
+				linkPath := c.sftpClient.Join(path, file.Name())
+				result[i].LinkTarget, _ = c.sftpClient.ReadLink(linkPath)
+				// Links to directories can be entered like directories
+				if target, err := c.sftpClient.Stat(linkPath); err == nil {
+					result[i].IsDir = target.IsDir()
+				}
+			}
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page(result)
 	}
 
-	return result, nil
+	return nil
+}
+
+// Stat returns information about a remote file, following symlinks
+func (c *Client) Stat(remotePath string) (FileInfo, error) {
+	info, err := c.sftpClient.Stat(remotePath)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+
+	perms := remotePermissions(info)
+	return FileInfo{
+		Name:    info.Name(),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+		Mode:    info.Mode(),
+		UID:     perms.UID,
+		GID:     perms.GID,
+	}, nil
 }
 
 // readPrivateKey reads the SSH private key, with optional passphrase support
@@ -184,18 +284,34 @@ func ListLocalDir(path string) ([]FileInfo, error) {
 		return nil, fmt.Errorf("failed to list local directory: %w", err)
 	}
 
-	result := make([]FileInfo, len(entries))
-	for i, entry := range entries {
+	result := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
 		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
-		result[i] = FileInfo{
+		perms := localPermissions(info)
+		file := FileInfo{
 			Name:    entry.Name(),
 			Size:    info.Size(),
 			ModTime: info.ModTime(),
 			IsDir:   entry.IsDir(),
+			Mode:    info.Mode(),
+			UID:     perms.UID,
+			GID:     perms.GID,
+			Owner:   localUserName(perms.UID),
+			Group:   localGroupName(perms.GID),
+			IsLink:  info.Mode()&os.ModeSymlink != 0,
+		}
+		if file.IsLink {
+			linkPath := filepath.Join(path, entry.Name())
+			file.LinkTarget, _ = os.Readlink(linkPath)
+			// Links to directories can be entered like directories
+			if target, err := os.Stat(linkPath); err == nil {
+				file.IsDir = target.IsDir()
+			}
 		}
+		result = append(result, file)
 	}
 
 	return result, nil
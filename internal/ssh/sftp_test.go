@@ -0,0 +1,59 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListDirPages(t *testing.T) {
+	client := newTestClient(t)
+	dir := t.TempDir()
+	total := ListPageSize + 10
+	for i := 0; i < total; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%04d", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var pages []int
+	seen := make(map[string]bool)
+	err := client.ListDirPages(context.Background(), dir, func(page []FileInfo) {
+		pages = append(pages, len(page))
+		for _, file := range page {
+			seen[file.Name] = true
+		}
+	})
+	if err != nil {
+		t.Fatalf("ListDirPages failed: %v", err)
+	}
+	if len(pages) != 2 || pages[0] != ListPageSize || pages[1] != 10 {
+		t.Errorf("Expected pages of %d and 10 entries, got %v", ListPageSize, pages)
+	}
+	if len(seen) != total {
+		t.Errorf("Expected %d distinct entries, got %d", total, len(seen))
+	}
+}
+
+func TestListDirPagesCancelled(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.ListDirPages(ctx, t.TempDir(), func([]FileInfo) {
+		t.Error("Expected no pages after cancellation")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestListDirMissing(t *testing.T) {
+	client := newTestClient(t)
+	if _, err := client.ListDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error listing a missing directory")
+	}
+}
@@ -0,0 +1,140 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/muesli/cancelreader"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// Shell is an interactive login shell on the remote host. It implements
+// tea.ExecCommand so the TUI can hand the terminal over while it runs.
+type Shell struct {
+	client *ssh.Client
+	dir    string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewShell prepares an interactive shell that starts in dir
+func (c *Client) NewShell(dir string) *Shell {
+	return &Shell{
+		client: c.sshClient,
+		dir:    dir,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// SetStdin sets the shell's input
+func (s *Shell) SetStdin(r io.Reader) { s.stdin = r }
+
+// SetStdout sets the shell's output
+func (s *Shell) SetStdout(w io.Writer) { s.stdout = w }
+
+// SetStderr sets the shell's error output
+func (s *Shell) SetStderr(w io.Writer) { s.stderr = w }
+
+// Run opens a session with a PTY, changes to the shell's directory and
+// waits until the user exits the shell
+func (s *Shell) Run() error {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	session.Stdout = s.stdout
+	session.Stderr = s.stderr
+
+	width, height := 80, 24
+	if file, ok := s.stdin.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		// Pass every key through untouched, the remote PTY does line editing
+		state, err := term.MakeRaw(int(file.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to put terminal in raw mode: %w", err)
+		}
+		defer term.Restore(int(file.Fd()), state)
+
+		if w, h, err := term.GetSize(int(file.Fd())); err == nil {
+			width, height = w, h
+		}
+		stop := watchWindowSize(file, session)
+		defer stop()
+	}
+
+	termType := os.Getenv("TERM")
+	if termType == "" {
+		termType = "xterm-256color"
+	}
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(termType, height, width, modes); err != nil {
+		return fmt.Errorf("failed to request PTY: %w", err)
+	}
+
+	// Feed the session through a pipe rather than session.Stdin, whose copy
+	// would go on reading the terminal after the shell exits and swallow the
+	// first key pressed back in the TUI
+	remoteStdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open shell input: %w", err)
+	}
+	stopInput, err := forwardInput(s.stdin, remoteStdin)
+	if err != nil {
+		return err
+	}
+	defer stopInput()
+
+	command := fmt.Sprintf(`cd %s && exec "${SHELL:-/bin/sh}" -l`, ShellQuote(s.dir))
+	if err := session.Start(command); err != nil {
+		return fmt.Errorf("failed to start remote shell: %w", err)
+	}
+
+	// The shell's exit status is just that of the last command typed in it
+	var exitErr *ssh.ExitError
+	var missingErr *ssh.ExitMissingError
+	if err := session.Wait(); err != nil && !errors.As(err, &exitErr) && !errors.As(err, &missingErr) {
+		return fmt.Errorf("remote shell failed: %w", err)
+	}
+	return nil
+}
+
+// forwardInput copies r to w in the background until stop is called. Once
+// stop returns nothing more is read from r, if r can be cancelled (terminals,
+// pipes and other files).
+func forwardInput(r io.Reader, w io.WriteCloser) (stop func(), err error) {
+	input, err := cancelreader.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(w, input)
+		w.Close()
+	}()
+
+	return func() {
+		if input.Cancel() {
+			<-done
+		}
+		input.Close()
+	}, nil
+}
+
+// ShellQuote quotes s as a single word for a POSIX shell
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
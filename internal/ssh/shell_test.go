@@ -0,0 +1,67 @@
+package ssh
+
+import (
+	"os"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"/var/log":    `'/var/log'`,
+		"my dir":      `'my dir'`,
+		"it's":        `'it'\''s'`,
+		"$(rm -rf /)": `'$(rm -rf /)'`,
+		"":            `''`,
+	}
+	for input, expected := range tests {
+		if got := ShellQuote(input); got != expected {
+			t.Errorf("ShellQuote(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
+
+// chanWriteCloser sends every write to a channel
+type chanWriteCloser chan string
+
+func (w chanWriteCloser) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func (w chanWriteCloser) Close() error { return nil }
+
+func TestForwardInputStopsReading(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+
+	forwarded := make(chanWriteCloser, 1)
+	stop, err := forwardInput(reader, forwarded)
+	if err != nil {
+		t.Fatalf("forwardInput failed: %v", err)
+	}
+	if _, err := writer.Write([]byte("ls\n")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if got := <-forwarded; got != "ls\n" {
+		t.Errorf("Expected the input to be forwarded, got %q", got)
+	}
+	stop()
+
+	// A key pressed after the shell is done must be left for the next reader
+	if _, err := writer.Write([]byte("q")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	buf := make([]byte, 1)
+	if _, err := reader.Read(buf); err != nil || buf[0] != 'q' {
+		t.Errorf("Expected the key to be read after stopping, got %q, %v", buf, err)
+	}
+	select {
+	case got := <-forwarded:
+		t.Errorf("Expected nothing to be forwarded after stopping, got %q", got)
+	default:
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package ssh
+
+import (
+	"os"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// watchWindowSize does nothing since Windows consoles have no resize signal
+func watchWindowSize(file *os.File, session *ssh.Session) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,193 @@
+package ssh
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SortKey is what a file listing is ordered by
+type SortKey int
+
+const (
+	SortByName SortKey = iota
+	SortBySize
+	SortByModTime
+	SortByExtension
+)
+
+// sortKeyNames are the names of the sort keys, also used to store them
+var sortKeyNames = []string{"name", "size", "mtime", "ext"}
+
+// String returns a short name for the sort key
+func (k SortKey) String() string {
+	if int(k) < len(sortKeyNames) {
+		return sortKeyNames[k]
+	}
+	return "unknown"
+}
+
+// Next returns the sort key that follows k in the cycle
+func (k SortKey) Next() SortKey {
+	return (k + 1) % SortKey(len(sortKeyNames))
+}
+
+// ParseSortKey returns the sort key with the given name
+func ParseSortKey(name string) (SortKey, bool) {
+	for i, keyName := range sortKeyNames {
+		if keyName == name {
+			return SortKey(i), true
+		}
+	}
+	return SortByName, false
+}
+
+// SortOrder describes how a file listing is ordered
+type SortOrder struct {
+	Key        SortKey
+	Descending bool
+	DirsFirst  bool
+}
+
+// DefaultSortOrder lists directories first, then files by name
+var DefaultSortOrder = SortOrder{Key: SortByName, DirsFirst: true}
+
+// String describes the order for display, e.g. "name ↑, dirs first"
+func (o SortOrder) String() string {
+	arrow := "↑"
+	if o.Descending {
+		arrow = "↓"
+	}
+	s := o.Key.String() + " " + arrow
+	if o.DirsFirst {
+		s += ", dirs first"
+	}
+	return s
+}
+
+// SortFiles orders files in place. Ties are broken by name so the order is
+// stable across reloads, and names compare case-insensitively with runs of
+// digits compared by value, so file9 comes before file10.
+func SortFiles(files []FileInfo, order SortOrder) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return lessFiles(files[i], files[j], order)
+	})
+}
+
+// MergeFiles returns sorted, which must already be in order, and batch
+// together in order. Adding a page of a listing this way is cheaper than
+// sorting everything again.
+func MergeFiles(sorted, batch []FileInfo, order SortOrder) []FileInfo {
+	SortFiles(batch, order)
+
+	merged := make([]FileInfo, 0, len(sorted)+len(batch))
+	i, j := 0, 0
+	for i < len(sorted) && j < len(batch) {
+		if lessFiles(batch[j], sorted[i], order) {
+			merged = append(merged, batch[j])
+			j++
+		} else {
+			merged = append(merged, sorted[i])
+			i++
+		}
+	}
+	merged = append(merged, sorted[i:]...)
+	return append(merged, batch[j:]...)
+}
+
+// lessFiles reports whether a comes before b in order
+func lessFiles(a, b FileInfo, order SortOrder) bool {
+	if order.DirsFirst && a.IsDir != b.IsDir {
+		// Directories stay on top whichever way the rest is ordered
+		return a.IsDir
+	}
+
+	cmp := compareFiles(a, b, order.Key)
+	if cmp == 0 && order.Key != SortByName {
+		cmp = compareFiles(a, b, SortByName)
+	}
+	if order.Descending {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// compareFiles compares two files by one key, returning -1, 0 or 1
+func compareFiles(a, b FileInfo, key SortKey) int {
+	switch key {
+	case SortBySize:
+		return compareInts(a.Size, b.Size)
+	case SortByModTime:
+		return a.ModTime.Compare(b.ModTime)
+	case SortByExtension:
+		return NaturalCompare(extension(a), extension(b))
+	}
+	return NaturalCompare(a.Name, b.Name)
+}
+
+// extension returns the extension of a file without its dot, "" for directories and dotfiles
+func extension(file FileInfo) string {
+	if file.IsDir {
+		return ""
+	}
+	return strings.TrimPrefix(path.Ext(strings.TrimPrefix(file.Name, ".")), ".")
+}
+
+// compareInts compares two integers, returning -1, 0 or 1
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// NaturalCompare compares two names case-insensitively, treating runs of
+// digits as numbers. Names that differ only in case or leading zeros are
+// ordered by their bytes so that the order is total.
+func NaturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA := strings.TrimLeft(a[startA:i], "0")
+			numB := strings.TrimLeft(b[startB:j], "0")
+			// Without leading zeros the longer run is the larger number
+			if cmp := compareInts(int64(len(numA)), int64(len(numB))); cmp != 0 {
+				return cmp
+			}
+			if cmp := strings.Compare(numA, numB); cmp != 0 {
+				return cmp
+			}
+			continue
+		}
+
+		runeA, sizeA := utf8.DecodeRuneInString(a[i:])
+		runeB, sizeB := utf8.DecodeRuneInString(b[j:])
+		if cmp := compareInts(int64(unicode.ToLower(runeA)), int64(unicode.ToLower(runeB))); cmp != 0 {
+			return cmp
+		}
+		i += sizeA
+		j += sizeB
+	}
+
+	if cmp := compareInts(int64(len(a)-i), int64(len(b)-j)); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(a, b)
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,88 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	ordered := []string{"a", "B", "file2", "File9", "file10", "file010b", "file10c", "img_1.png", "img_12.png", "zeta"}
+	for i := 0; i < len(ordered)-1; i++ {
+		if NaturalCompare(ordered[i], ordered[i+1]) >= 0 {
+			t.Errorf("Expected %q before %q", ordered[i], ordered[i+1])
+		}
+		if NaturalCompare(ordered[i+1], ordered[i]) <= 0 {
+			t.Errorf("Expected %q after %q", ordered[i+1], ordered[i])
+		}
+	}
+	if NaturalCompare("Same", "Same") != 0 {
+		t.Error("Expected equal names to compare equal")
+	}
+	if NaturalCompare("readme", "README") == 0 || NaturalCompare("file1", "file01") == 0 {
+		t.Error("Names differing in case or leading zeros must still be ordered")
+	}
+}
+
+func TestSortFiles(t *testing.T) {
+	now := time.Now()
+	files := func() []FileInfo {
+		return []FileInfo{
+			{Name: "notes.txt", Size: 300, ModTime: now.Add(-time.Hour)},
+			{Name: "src", IsDir: true, ModTime: now},
+			{Name: "archive.tar.gz", Size: 5000, ModTime: now.Add(-48 * time.Hour)},
+			{Name: "build", IsDir: true, ModTime: now.Add(-time.Minute)},
+			{Name: ".env", Size: 10, ModTime: now.Add(-2 * time.Hour)},
+			{Name: "app.go", Size: 300, ModTime: now.Add(-3 * time.Hour)},
+		}
+	}
+	names := func(files []FileInfo) string {
+		var s []string
+		for _, file := range files {
+			s = append(s, file.Name)
+		}
+		return strings.Join(s, " ")
+	}
+
+	tests := []struct {
+		order    SortOrder
+		expected string
+	}{
+		{DefaultSortOrder, "build src .env app.go archive.tar.gz notes.txt"},
+		{SortOrder{Key: SortByName}, ".env app.go archive.tar.gz build notes.txt src"},
+		{SortOrder{Key: SortBySize, DirsFirst: true}, "build src .env app.go notes.txt archive.tar.gz"},
+		{SortOrder{Key: SortBySize, Descending: true, DirsFirst: true}, "src build archive.tar.gz notes.txt app.go .env"},
+		{SortOrder{Key: SortByModTime, Descending: true}, "src build notes.txt .env app.go archive.tar.gz"},
+		{SortOrder{Key: SortByExtension, DirsFirst: true}, "build src .env app.go archive.tar.gz notes.txt"},
+	}
+	for _, test := range tests {
+		sorted := files()
+		SortFiles(sorted, test.order)
+		if got := names(sorted); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.order, test.expected, got)
+		}
+
+		// Merging pages must give the same order as sorting them all at once
+		all := files()
+		merged := MergeFiles(nil, all[:2], test.order)
+		merged = MergeFiles(merged, all[2:5], test.order)
+		merged = MergeFiles(merged, all[5:], test.order)
+		if got := names(merged); got != test.expected {
+			t.Errorf("%s merged: expected %q, got %q", test.order, test.expected, got)
+		}
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	for key := SortByName; key <= SortByExtension; key++ {
+		if parsed, ok := ParseSortKey(key.String()); !ok || parsed != key {
+			t.Errorf("ParseSortKey(%q) = %v, %v", key.String(), parsed, ok)
+		}
+	}
+	if _, ok := ParseSortKey("colour"); ok {
+		t.Error("Expected an unknown sort key to be rejected")
+	}
+	if SortByExtension.Next() != SortByName {
+		t.Error("Expected the sort keys to cycle")
+	}
+}
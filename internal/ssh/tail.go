@@ -0,0 +1,87 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// followHeadBytes is how much of the start of a followed file is remembered
+// to notice when it has been replaced by log rotation
+const followHeadBytes = 256
+
+// FollowState tracks the read position of a followed remote file between polls
+type FollowState struct {
+	Offset int64
+	Head   []byte // First bytes of the file, used to detect rotation
+}
+
+// StartFollow prepares to follow a remote file, starting backlog bytes before its end
+func (c *Client) StartFollow(remotePath string, backlog int64) (FollowState, error) {
+	info, err := c.sftpClient.Stat(remotePath)
+	if err != nil {
+		return FollowState{}, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+
+	head, err := c.readAt(remotePath, 0, followHeadBytes)
+	if err != nil {
+		return FollowState{}, err
+	}
+
+	return FollowState{
+		Offset: max(0, info.Size()-backlog),
+		Head:   head,
+	}, nil
+}
+
+// ReadAppended returns up to limit bytes appended to a remote file since the
+// last poll. When the file was truncated or replaced, reading restarts from
+// its beginning and reset is true.
+func (c *Client) ReadAppended(remotePath string, state FollowState, limit int64) ([]byte, FollowState, bool, error) {
+	info, err := c.sftpClient.Stat(remotePath)
+	if err != nil {
+		return nil, state, false, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+
+	head, err := c.readAt(remotePath, 0, followHeadBytes)
+	if err != nil {
+		return nil, state, false, err
+	}
+
+	reset := false
+	// A shrinking file was truncated; a file whose first bytes changed was
+	// rotated and replaced by a new one (which may already be larger)
+	known := min(len(head), len(state.Head))
+	if info.Size() < state.Offset || !bytes.Equal(head[:known], state.Head[:known]) {
+		state.Offset = 0
+		reset = true
+	}
+	state.Head = head
+
+	if info.Size() == state.Offset {
+		return nil, state, reset, nil
+	}
+
+	data, err := c.readAt(remotePath, state.Offset, min(limit, info.Size()-state.Offset))
+	if err != nil {
+		return nil, state, reset, err
+	}
+	state.Offset += int64(len(data))
+	return data, state, reset, nil
+}
+
+// readAt reads up to n bytes of a remote file starting at offset
+func (c *Client) readAt(remotePath string, offset, n int64) ([]byte, error) {
+	file, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", remotePath, err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, n)
+	read, err := file.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read %s: %w", remotePath, err)
+	}
+	return buf[:read], nil
+}
@@ -0,0 +1,65 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadAppended(t *testing.T) {
+	client := newTestClient(t)
+	logPath := filepath.Join(t.TempDir(), "app.log")
+
+	if err := os.WriteFile(logPath, []byte("line 1\nline 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to create log: %v", err)
+	}
+
+	state, err := client.StartFollow(logPath, 7)
+	if err != nil {
+		t.Fatalf("StartFollow failed: %v", err)
+	}
+	if state.Offset != 7 {
+		t.Errorf("Expected to start 7 bytes before the end, got offset %d", state.Offset)
+	}
+
+	data, state, reset, err := client.ReadAppended(logPath, state, 1024)
+	if err != nil || reset || string(data) != "line 2\n" {
+		t.Fatalf("Expected backlog 'line 2', got %q (reset=%v, err=%v)", data, reset, err)
+	}
+
+	appendFile(t, logPath, "line 3\n")
+	data, state, reset, err = client.ReadAppended(logPath, state, 1024)
+	if err != nil || reset || string(data) != "line 3\n" {
+		t.Fatalf("Expected appended 'line 3', got %q (reset=%v, err=%v)", data, reset, err)
+	}
+
+	// Truncation restarts from the beginning
+	if err := os.WriteFile(logPath, []byte("new\n"), 0644); err != nil {
+		t.Fatalf("Failed to truncate log: %v", err)
+	}
+	data, state, reset, err = client.ReadAppended(logPath, state, 1024)
+	if err != nil || !reset || string(data) != "new\n" {
+		t.Fatalf("Expected reset with 'new', got %q (reset=%v, err=%v)", data, reset, err)
+	}
+
+	// Rotation to a different file that is already larger is detected from its head
+	if err := os.WriteFile(logPath, []byte("rotated file with more content\n"), 0644); err != nil {
+		t.Fatalf("Failed to rotate log: %v", err)
+	}
+	data, _, reset, err = client.ReadAppended(logPath, state, 1024)
+	if err != nil || !reset || string(data) != "rotated file with more content\n" {
+		t.Fatalf("Expected reset after rotation, got %q (reset=%v, err=%v)", data, reset, err)
+	}
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatalf("Failed to append to log: %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package ui
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	// Syntax highlighting styles
+	KeywordStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("205"))
+
+	StringStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("114"))
+
+	CommentStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("243")).
+			Italic(true)
+
+	NumberStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("179"))
+
+	KeyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("75"))
+)
+
+// syntax describes how to highlight one family of file formats
+type syntax struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	keyValue     bool // Highlight "key:" / "key =" at the start of lines
+}
+
+// words builds a keyword set from a space separated list
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(list) {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	goSyntax = syntax{
+		keywords: words("break case chan const continue default defer else fallthrough for func go goto if " +
+			"import interface map package range return select struct switch type var nil true false"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	}
+	cSyntax = syntax{
+		keywords: words("auto break case char class const continue default do double else enum extern float for " +
+			"goto if int long namespace new private protected public register return short signed sizeof static " +
+			"struct switch template this typedef union unsigned virtual void volatile while fn let mut impl trait " +
+			"pub use mod match loop final abstract extends implements import package throws try catch finally " +
+			"true false null nullptr"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+	}
+	jsSyntax = syntax{
+		keywords: words("async await break case catch class const continue default delete do else export extends " +
+			"finally for from function if import in instanceof interface let new of return static super switch " +
+			"this throw try type typeof var void while yield true false null undefined"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	}
+	pythonSyntax = syntax{
+		keywords: words("and as assert async await break class continue def del elif else except finally for " +
+			"from global if import in is lambda nonlocal not or pass raise return try while with yield " +
+			"True False None self"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	}
+	shellSyntax = syntax{
+		keywords: words("if then else elif fi case esac for while until do done in function return local " +
+			"export readonly set unset shift exit echo source alias"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	}
+	configSyntax = syntax{
+		keywords:     words("true false yes no on off null"),
+		lineComments: []string{"#", ";"},
+		quotes:       "\"'",
+		keyValue:     true,
+	}
+	jsonSyntax = syntax{
+		keywords: words("true false null"),
+		quotes:   "\"",
+		keyValue: true,
+	}
+)
+
+// syntaxes maps file extensions and well-known names to their syntax
+var syntaxes = map[string]syntax{
+	".go": goSyntax,
+	".c":  cSyntax, ".h": cSyntax, ".cc": cSyntax, ".cpp": cSyntax, ".hpp": cSyntax,
+	".java": cSyntax, ".rs": cSyntax, ".cs": cSyntax, ".kt": cSyntax, ".swift": cSyntax,
+	".js": jsSyntax, ".jsx": jsSyntax, ".ts": jsSyntax, ".tsx": jsSyntax, ".mjs": jsSyntax,
+	".py": pythonSyntax,
+	".sh": shellSyntax, ".bash": shellSyntax, ".zsh": shellSyntax, ".bashrc": shellSyntax,
+	".profile": shellSyntax, ".zshrc": shellSyntax,
+	".yaml": configSyntax, ".yml": configSyntax, ".toml": configSyntax, ".ini": configSyntax,
+	".conf": configSyntax, ".cfg": configSyntax, ".env": configSyntax, ".properties": configSyntax,
+	"dockerfile": shellSyntax, "makefile": shellSyntax,
+	".json": jsonSyntax,
+}
+
+// Highlight applies syntax highlighting to content based on the file name.
+// Content in unknown formats is returned with only control characters made safe.
+func Highlight(content, fileName string) string {
+	lang, ok := syntaxes[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		lang, ok = syntaxes[strings.ToLower(filepath.Base(fileName))]
+	}
+
+	lines := strings.Split(content, "\n")
+	inBlock := false
+	for i, line := range lines {
+		line = SanitizeLine(line)
+		if ok {
+			line, inBlock = lang.highlightLine(line, inBlock)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SanitizeLine expands tabs and replaces control characters that would
+// otherwise be interpreted by the terminal
+func SanitizeLine(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	line = strings.ReplaceAll(line, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return '·'
+		}
+		return r
+	}, line)
+}
+
+// HighlightMatches highlights every match of pattern in an already sanitized line
+func HighlightMatches(line string, pattern *regexp.Regexp) string {
+	matches := pattern.FindAllStringIndex(line, -1)
+	if len(matches) == 0 {
+		return line
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, match := range matches {
+		if match[0] == match[1] {
+			continue
+		}
+		out.WriteString(line[last:match[0]])
+		out.WriteString(MatchStyle.Render(line[match[0]:match[1]]))
+		last = match[1]
+	}
+	out.WriteString(line[last:])
+	return out.String()
+}
+
+// highlightLine highlights one line, tracking whether it ends inside a block comment
+func (s syntax) highlightLine(line string, inBlock bool) (string, bool) {
+	var out strings.Builder
+	i := 0
+
+	if inBlock {
+		end := strings.Index(line, s.blockComment[1])
+		if end < 0 {
+			return CommentStyle.Render(line), true
+		}
+		end += len(s.blockComment[1])
+		out.WriteString(CommentStyle.Render(line[:end]))
+		i = end
+	}
+
+	if s.keyValue && i == 0 {
+		if key, rest, found := splitKey(line); found {
+			out.WriteString(KeyStyle.Render(key))
+			i = len(line) - len(rest)
+		}
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+
+		if s.blockComment[0] != "" && strings.HasPrefix(rest, s.blockComment[0]) {
+			end := strings.Index(rest[len(s.blockComment[0]):], s.blockComment[1])
+			if end < 0 {
+				out.WriteString(CommentStyle.Render(rest))
+				return out.String(), true
+			}
+			end += len(s.blockComment[0]) + len(s.blockComment[1])
+			out.WriteString(CommentStyle.Render(rest[:end]))
+			i += end
+			continue
+		}
+
+		if s.isLineComment(line, i) {
+			out.WriteString(CommentStyle.Render(rest))
+			break
+		}
+
+		c := line[i]
+		switch {
+		case strings.IndexByte(s.quotes, c) >= 0:
+			end := closingQuote(rest, c)
+			out.WriteString(StringStyle.Render(rest[:end]))
+			i += end
+		case c >= '0' && c <= '9' && (i == 0 || !isWordByte(line[i-1])):
+			end := numberEnd(rest)
+			out.WriteString(NumberStyle.Render(rest[:end]))
+			i += end
+		case isWordByte(c):
+			end := wordEnd(rest)
+			if s.keywords[rest[:end]] {
+				out.WriteString(KeywordStyle.Render(rest[:end]))
+			} else {
+				out.WriteString(rest[:end])
+			}
+			i += end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	return out.String(), false
+}
+
+// isLineComment reports whether a line comment starts at position i. Shell
+// style "#" only counts at the start of a word so that e.g. "$#" is not a comment.
+func (s syntax) isLineComment(line string, i int) bool {
+	for _, marker := range s.lineComments {
+		if !strings.HasPrefix(line[i:], marker) {
+			continue
+		}
+		if marker != "#" || i == 0 || line[i-1] == ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// splitKey splits a leading "key:" or "key =" from a config line
+func splitKey(line string) (string, string, bool) {
+	end := strings.IndexAny(line, ":=")
+	if end <= 0 {
+		return "", line, false
+	}
+
+	key := strings.TrimSpace(line[:end])
+	key = strings.TrimPrefix(key, "- ") // YAML list items
+	key = strings.Trim(key, `"'`)       // JSON keys are quoted
+	if key == "" || strings.ContainsAny(key, " \t#;{}[],") {
+		return "", line, false
+	}
+	return line[:end], line[end:], true
+}
+
+// closingQuote returns the length of the quoted string at the start of s
+func closingQuote(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// wordEnd returns the length of the identifier at the start of s
+func wordEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		if !isWordByte(s[i]) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// numberEnd returns the length of the number at the start of s
+func numberEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		if !isWordByte(s[i]) && s[i] != '.' {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// isWordByte reports whether c can be part of an identifier
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
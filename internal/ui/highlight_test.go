@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestHighlight(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+
+	content := "package main\n/* block\ncomment */ func x() { return \"s\" } // done\n"
+	highlighted := Highlight(content, "main.go")
+
+	lines := strings.Split(highlighted, "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected line count to be preserved, got %d lines", len(lines))
+	}
+	if !strings.Contains(lines[0], KeywordStyle.Render("package")) {
+		t.Errorf("Expected 'package' to be highlighted as a keyword, got %q", lines[0])
+	}
+	if lines[1] != CommentStyle.Render("/* block") {
+		t.Errorf("Expected block comment start to be highlighted, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], CommentStyle.Render("comment */")) {
+		t.Errorf("Expected block comment to continue onto the next line, got %q", lines[2])
+	}
+	if !strings.Contains(lines[2], StringStyle.Render(`"s"`)) {
+		t.Errorf("Expected string literal to be highlighted, got %q", lines[2])
+	}
+	if !strings.HasSuffix(lines[2], CommentStyle.Render("// done")) {
+		t.Errorf("Expected trailing line comment to be highlighted, got %q", lines[2])
+	}
+}
+
+func TestHighlightSanitizesControlCharacters(t *testing.T) {
+	highlighted := Highlight("a\x1b[2Jb\tc\r", "notes.unknown")
+	if highlighted != "a·[2Jb    c" {
+		t.Errorf("Expected control characters to be replaced, got %q", highlighted)
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+
+	pattern := regexp.MustCompile(`err\w*`)
+	line := "error: disk full, errno 28"
+
+	expected := MatchStyle.Render("error") + ": disk full, " + MatchStyle.Render("errno") + " 28"
+	if got := HighlightMatches(line, pattern); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got := HighlightMatches("all good", pattern); got != "all good" {
+		t.Errorf("Expected line without matches to be unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Severity is how serious a notification is
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// String returns a short name for the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "info"
+}
+
+// icon returns the symbol shown in front of notifications of the severity
+func (s Severity) icon() string {
+	switch s {
+	case SeverityWarning:
+		return "⚠"
+	case SeverityError:
+		return "✗"
+	}
+	return "•"
+}
+
+// style returns the style of notifications of the severity
+func (s Severity) style() lipgloss.Style {
+	switch s {
+	case SeverityWarning:
+		return WarningStyle
+	case SeverityError:
+		return ErrorStyle
+	}
+	return InfoStyle
+}
+
+// toastDurations is how long a toast of each severity stays on screen
+var toastDurations = map[Severity]time.Duration{
+	SeverityInfo:    3 * time.Second,
+	SeverityWarning: 5 * time.Second,
+	SeverityError:   8 * time.Second,
+}
+
+const (
+	maxToasts     = 3   // Older toasts leave the screen early but stay in the log
+	maxLogEntries = 200 // Oldest entries are dropped from the log beyond this
+)
+
+// Notification is a message for the user
+type Notification struct {
+	ID       int
+	Severity Severity
+	Message  string
+	Time     time.Time
+}
+
+// DismissMsg takes a toast off the screen once its time is up
+type DismissMsg struct {
+	ID int
+}
+
+// Notifier shows notifications as toasts that go away by themselves, and
+// keeps a log of them to look through later
+type Notifier struct {
+	toasts    []Notification
+	log       []Notification
+	nextID    int
+	logOffset int // First visible entry of the log view, counted from the newest
+}
+
+// NewNotifier creates a notifier without any notifications
+func NewNotifier() *Notifier {
+	return &Notifier{}
+}
+
+// Notify shows a notification and returns the command that dismisses it
+func (n *Notifier) Notify(severity Severity, message string) tea.Cmd {
+	n.nextID++
+	note := Notification{ID: n.nextID, Severity: severity, Message: message, Time: time.Now()}
+
+	n.toasts = append(n.toasts, note)
+	if len(n.toasts) > maxToasts {
+		n.toasts = n.toasts[len(n.toasts)-maxToasts:]
+	}
+	n.log = append(n.log, note)
+	if len(n.log) > maxLogEntries {
+		n.log = n.log[len(n.log)-maxLogEntries:]
+	}
+
+	return tea.Tick(toastDurations[severity], func(time.Time) tea.Msg {
+		return DismissMsg{ID: note.ID}
+	})
+}
+
+// Info shows an informational notification
+func (n *Notifier) Info(message string) tea.Cmd {
+	return n.Notify(SeverityInfo, message)
+}
+
+// Warn shows a warning
+func (n *Notifier) Warn(message string) tea.Cmd {
+	return n.Notify(SeverityWarning, message)
+}
+
+// Error shows an error
+func (n *Notifier) Error(err error) tea.Cmd {
+	return n.Notify(SeverityError, err.Error())
+}
+
+// Dismiss takes a toast off the screen, keeping it in the log
+func (n *Notifier) Dismiss(id int) {
+	for i, toast := range n.toasts {
+		if toast.ID == id {
+			n.toasts = append(n.toasts[:i], n.toasts[i+1:]...)
+			return
+		}
+	}
+}
+
+// Toasts returns the notifications on screen, oldest first
+func (n *Notifier) Toasts() []Notification {
+	return n.toasts
+}
+
+// Log returns the notifications kept in the log, oldest first
+func (n *Notifier) Log() []Notification {
+	return n.log
+}
+
+// ClearLog empties the log and takes all toasts off the screen
+func (n *Notifier) ClearLog() {
+	n.log = nil
+	n.toasts = nil
+	n.logOffset = 0
+}
+
+// ToastView renders the toasts on screen one per line, cut to width, or ""
+// when there are none
+func (n *Notifier) ToastView(width int) string {
+	lines := make([]string, len(n.toasts))
+	for i, toast := range n.toasts {
+		line := fmt.Sprintf("%s %s", toast.Severity.icon(), toast.Message)
+		lines[i] = toast.Severity.style().MaxWidth(width).Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// logLines returns how many lines of log entries fit in a log view of the given height
+func logLines(height int) int {
+	return max(1, height-6) // Title, help and dialog frame
+}
+
+// logWidth returns how wide the lines of a log view of the given width can be
+func logWidth(width int) int {
+	return max(20, width-8) // Dialog border and padding
+}
+
+// logEntryLines renders a log entry as lines of at most width cells, wrapping
+// its message under the time and icon
+func logEntryLines(note Notification, width int) []string {
+	prefix := fmt.Sprintf("%s %s ", note.Time.Format("15:04:05"), note.Severity.icon())
+	indent := lipgloss.Width(prefix)
+	wrapped := lipgloss.NewStyle().Width(max(1, width-indent)).Render(note.Message)
+	lines := strings.Split(wrapped, "\n")
+	for i, line := range lines {
+		if i == 0 {
+			line = prefix + line
+		} else {
+			line = strings.Repeat(" ", indent) + line
+		}
+		lines[i] = note.Severity.style().Render(strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+// lastLogOffset returns the offset that shows the oldest entries at the
+// bottom of a log view, so scrolling stops there
+func (n *Notifier) lastLogOffset(width, height int) int {
+	lines := 0
+	for i := len(n.log) - 1; i >= 0; i-- {
+		lines += len(logEntryLines(n.log[len(n.log)-1-i], logWidth(width)))
+		if lines > logLines(height) {
+			return min(i+1, len(n.log)-1)
+		}
+	}
+	return 0
+}
+
+// ScrollLog scrolls a log view of the given size by delta entries
+func (n *Notifier) ScrollLog(delta, width, height int) {
+	n.logOffset = max(0, min(n.lastLogOffset(width, height), n.logOffset+delta))
+}
+
+// LogView renders the log, newest first, as a dialog fitting in width and
+// height. Long entries wrap; the first one shown is cut if even it doesn't fit.
+func (n *Notifier) LogView(width, height int) string {
+	var s strings.Builder
+	s.WriteString(HeaderStyle.Render(fmt.Sprintf("Notifications (%d)", len(n.log))) + "\n\n")
+
+	if len(n.log) == 0 {
+		s.WriteString(DimRowStyle.Render("Nothing to report") + "\n")
+	}
+	rows := logLines(height)
+	for i := n.logOffset; i < len(n.log) && rows > 0; i++ {
+		lines := logEntryLines(n.log[len(n.log)-1-i], logWidth(width))
+		if len(lines) > rows && i > n.logOffset {
+			break
+		}
+		lines = lines[:min(len(lines), rows)]
+		rows -= len(lines)
+		s.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+
+	s.WriteString(HelpStyle.Render("↑/↓/PgUp/PgDn: scroll • c: clear • esc: close"))
+	return DialogStyle.Render(s.String())
+}
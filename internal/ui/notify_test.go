@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestNotifierToasts(t *testing.T) {
+	n := NewNotifier()
+	if cmd := n.Info("copied"); cmd == nil {
+		t.Fatal("Expected a command that dismisses the toast")
+	}
+	n.Error(errors.New("permission denied"))
+	if len(n.Toasts()) != 2 || n.Toasts()[1].Severity != SeverityError {
+		t.Fatalf("Expected two toasts, the last an error, got %+v", n.Toasts())
+	}
+
+	n.Dismiss(n.Toasts()[0].ID)
+	if len(n.Toasts()) != 1 || n.Toasts()[0].Message != "permission denied" {
+		t.Errorf("Expected only the error to be left on screen, got %+v", n.Toasts())
+	}
+	if len(n.Log()) != 2 {
+		t.Errorf("Expected dismissed toasts to stay in the log, got %d entries", len(n.Log()))
+	}
+	if view := n.ToastView(80); !strings.Contains(view, "permission denied") || strings.Contains(view, "copied") {
+		t.Errorf("Expected only the remaining toast to be rendered, got %q", view)
+	}
+}
+
+func TestNotifierLimits(t *testing.T) {
+	n := NewNotifier()
+	for i := 0; i < maxLogEntries+5; i++ {
+		n.Warn(fmt.Sprintf("warning %d", i))
+	}
+	if len(n.Toasts()) != maxToasts {
+		t.Errorf("Expected at most %d toasts, got %d", maxToasts, len(n.Toasts()))
+	}
+	if len(n.Log()) != maxLogEntries || n.Log()[0].Message != "warning 5" {
+		t.Errorf("Expected the oldest log entries to be dropped, got %d starting with %q", len(n.Log()), n.Log()[0].Message)
+	}
+
+	// The log view lists the newest first and scrolls towards older ones
+	if view := n.LogView(80, 20); !strings.Contains(view, fmt.Sprintf("warning %d", maxLogEntries+4)) {
+		t.Error("Expected the newest entry at the top of the log")
+	}
+	n.ScrollLog(1000, 80, 20)
+	if view := n.LogView(80, 20); !regexp.MustCompile(`warning 5\s`).MatchString(view) {
+		t.Error("Expected scrolling to reach the oldest entry")
+	}
+
+	n.ClearLog()
+	if len(n.Log()) != 0 || len(n.Toasts()) != 0 {
+		t.Error("Expected clearing to remove everything")
+	}
+}
+
+func TestLogViewWrapsEntries(t *testing.T) {
+	n := NewNotifier()
+	n.Error(errors.New("failed to copy /data/reports/2024/quarterly-summary.pdf: permission denied by the remote server"))
+	n.Info("copied")
+
+	view := n.LogView(60, 20)
+	if !strings.Contains(view, "permission denied") || !strings.Contains(view, "remote server") {
+		t.Errorf("Expected the long entry to wrap instead of being cut:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if lipgloss.Width(line) > 60 {
+			t.Errorf("Expected lines to fit in 60 cells, got %d: %q", lipgloss.Width(line), line)
+		}
+	}
+
+	// Scrolling stops once the wrapped oldest entry is at the bottom
+	n.ScrollLog(1000, 60, 9)
+	if view := n.LogView(60, 9); !strings.Contains(view, "remote server") || strings.Contains(view, "copied") {
+		t.Errorf("Expected only the oldest entry after scrolling to the end:\n%s", view)
+	}
+}
@@ -42,4 +42,26 @@ var (
 	ErrorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FF0000")).
 			Bold(true)
+
+	// Notification styles, errors use ErrorStyle
+	InfoStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("69"))
+
+	WarningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+
+	// Error output of remote commands
+	StderrStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("203"))
+
+	// Dialog style
+	DialogStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("69")).
+			Padding(1, 2)
+
+	// Search match style
+	MatchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("220"))
 )